@@ -0,0 +1,212 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"httpsignatures/sfv"
+)
+
+const acceptSignatureHeader = "Accept-Signature"
+
+// AcceptSignatureOptions options of the signature requested by the server (RFC 9421 5.1)
+// Label signature label (default: sig1)
+// KeyID requested key (optional). Requested algorithm is taken from the key
+// Nonce & Tag requested nonce & tag params (optional)
+// Expires request `expires` param
+type AcceptSignatureOptions struct {
+	Label   string
+	KeyID   string
+	Nonce   string
+	Tag     string
+	Expires bool
+}
+
+// AcceptedSignature signature requested by the server with Accept-Signature header
+// KeyID requested key (empty if not requested)
+// Algorithm requested signature hash algorithm, e.g. RSA-SHA256 (empty if not requested)
+// Options options to sign the request with
+type AcceptedSignature struct {
+	KeyID     string
+	Algorithm string
+	Options   MessageSignatureOptions
+}
+
+// AcceptSignature create Accept-Signature header value from the verification policy: covered components are
+// the required components of the policy, algorithm is the algorithm of the requested key or the only algorithm
+// allowed by the policy
+func (hs *HTTPSignatures) AcceptSignature(o AcceptSignatureOptions) (string, error) {
+	hs.mu.RLock()
+	p := hs.p
+	hs.mu.RUnlock()
+
+	input := sfv.InnerList{Items: make([]sfv.Item, 0, len(p.RequiredComponents))}
+	for _, c := range p.RequiredComponents {
+		item, err := hs.parseComponent(c)
+		if err != nil {
+			return "", err
+		}
+		input.Items = append(input.Items, item)
+	}
+
+	input.Params.Set("created", true)
+	if o.Expires {
+		input.Params.Set("expires", true)
+	}
+	if len(o.Nonce) > 0 {
+		input.Params.Set("nonce", o.Nonce)
+	}
+	alg, err := hs.acceptedAlgorithm(p, o.KeyID)
+	if err != nil {
+		return "", err
+	}
+	if len(alg) > 0 {
+		input.Params.Set("alg", alg)
+	}
+	if len(o.KeyID) > 0 {
+		input.Params.Set("keyid", o.KeyID)
+	}
+	if len(o.Tag) > 0 {
+		input.Params.Set("tag", o.Tag)
+	}
+
+	label := o.Label
+	if len(label) == 0 {
+		label = defaultSignatureLabel
+	}
+	h, err := sfv.SerializeDictionary(sfv.Dictionary{{Key: label, Value: input}})
+	if err != nil {
+		return "", &Error{"error serializing accept signature", err}
+	}
+	return h, nil
+}
+
+// WriteUnauthorized add Accept-Signature header to the response & write 401 Unauthorized status
+func (hs *HTTPSignatures) WriteUnauthorized(w http.ResponseWriter, o AcceptSignatureOptions) error {
+	h, err := hs.AcceptSignature(o)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(acceptSignatureHeader, h)
+	w.WriteHeader(http.StatusUnauthorized)
+	return nil
+}
+
+// ParseAcceptSignature parse signatures requested by the server with Accept-Signature header of the response
+func (hs *HTTPSignatures) ParseAcceptSignature(resp *http.Response) ([]AcceptedSignature, error) {
+	d, err := hs.parseDictionaryHeader(resp.Header, acceptSignatureHeader)
+	if err != nil {
+		return nil, err
+	}
+	if len(d) == 0 {
+		return nil, &Error{"accept-signature header not found", withKind(nil, ErrMissingHeader)}
+	}
+	hs.mu.RLock()
+	expiresPeriod := hs.expiresPeriod
+	hs.mu.RUnlock()
+
+	accepted := make([]AcceptedSignature, 0, len(d))
+	for _, m := range d {
+		input, ok := m.Value.(sfv.InnerList)
+		if !ok {
+			return nil, &Error{
+				fmt.Sprintf("requested signature '%s' must be an inner list", m.Key),
+				withKind(nil, ErrMalformedHeader),
+			}
+		}
+		a := AcceptedSignature{
+			Options: MessageSignatureOptions{
+				Label:      m.Key,
+				Components: make([]string, 0, len(input.Items)),
+			},
+		}
+		for _, c := range input.Items {
+			id, err := sfv.SerializeItem(c)
+			if err != nil {
+				return nil, &Error{"wrong component identifier", err}
+			}
+			a.Options.Components = append(a.Options.Components, id)
+		}
+
+		a.KeyID, _ = hs.stringParam(input.Params, "keyid")
+		a.Options.Nonce, _ = hs.stringParam(input.Params, "nonce")
+		a.Options.Tag, _ = hs.stringParam(input.Params, "tag")
+		if _, ok := input.Params.Get("expires"); ok {
+			a.Options.Expires = time.Now().Add(expiresPeriod)
+		}
+		if name, ok := hs.stringParam(input.Params, "alg"); ok {
+			if a.Algorithm, ok = messageSignatureAlgorithms[name]; !ok {
+				return nil, &Error{
+					fmt.Sprintf("requested algorithm '%s' not supported", name),
+					withKind(nil, ErrWrongAlgorithm),
+				}
+			}
+			a.Options.Alg = true
+		}
+		accepted = append(accepted, a)
+	}
+	return accepted, nil
+}
+
+// SignAcceptedMessage sign the request with all signatures requested by Accept-Signature header of the response.
+// Secrets are taken by requested keyID, defaultKeyID is used if key is not requested
+func (hs *HTTPSignatures) SignAcceptedMessage(r *http.Request, resp *http.Response, defaultKeyID string) error {
+	accepted, err := hs.ParseAcceptSignature(resp)
+	if err != nil {
+		return err
+	}
+	for _, a := range accepted {
+		keyID := a.KeyID
+		if len(keyID) == 0 {
+			keyID = defaultKeyID
+		}
+		secret, err := hs.ss.Get(keyID)
+		if err != nil {
+			return &Error{fmt.Sprintf("keyID '%s' not found", keyID), withKind(err, ErrKeyNotFound)}
+		}
+		if len(a.Algorithm) > 0 && !strings.EqualFold(secret.Algorithm, a.Algorithm) {
+			return &Error{
+				fmt.Sprintf("wrong algorithm '%s' for keyID '%s'", a.Algorithm, keyID),
+				withKind(nil, ErrWrongAlgorithm),
+			}
+		}
+		if err = hs.SignMessage(secret, r, a.Options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// acceptedAlgorithm RFC 9421 name of the algorithm of the requested key or of the only RFC 9421 algorithm
+// allowed by the policy
+func (hs *HTTPSignatures) acceptedAlgorithm(p Policy, keyID string) (string, error) {
+	if len(keyID) > 0 {
+		secret, err := hs.ss.Get(keyID)
+		if err != nil {
+			return "", &Error{fmt.Sprintf("keyID '%s' not found", keyID), withKind(err, ErrKeyNotFound)}
+		}
+		if !p.isSignatureAlgorithmAllowed(secret.Algorithm) {
+			return "", &Error{
+				fmt.Sprintf("algorithm '%s' not allowed by policy", secret.Algorithm),
+				withKind(nil, ErrPolicyViolation),
+			}
+		}
+		name, _ := hs.messageAlgorithmName(secret.Algorithm)
+		return name, nil
+	}
+
+	var alg string
+	for _, a := range p.SignatureAlgorithms {
+		name, ok := hs.messageAlgorithmName(a)
+		if !ok {
+			continue
+		}
+		if len(alg) > 0 && alg != name {
+			return "", nil
+		}
+		alg = name
+	}
+	return alg, nil
+}
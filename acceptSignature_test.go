@@ -0,0 +1,247 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAcceptSignature(t *testing.T) {
+	type args struct {
+		policy Policy
+		o      AcceptSignatureOptions
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        string
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Empty policy",
+			args: args{
+				policy: Policy{},
+				o:      AcceptSignatureOptions{},
+			},
+			want:        `sig1=();created`,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Required components & key",
+			args: args{
+				policy: Policy{RequiredComponents: []string{"@method", "@path", "content-digest"}},
+				o: AcceptSignatureOptions{
+					Label:   "req",
+					KeyID:   "test-key-rsa",
+					Nonce:   "nonce",
+					Tag:     "app-123",
+					Expires: true,
+				},
+			},
+			want: `req=("@method" "@path" "content-digest");created;expires;nonce="nonce";alg="rsa-v1_5-sha256";` +
+				`keyid="test-key-rsa";tag="app-123"`,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Single algorithm allowed by policy",
+			args: args{
+				policy: Policy{SignatureAlgorithms: []string{algoHmacSha256, algoHmacSha512}},
+				o:      AcceptSignatureOptions{},
+			},
+			want:        `sig1=();created;alg="hmac-sha256"`,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Several algorithms allowed by policy",
+			args: args{
+				policy: NewStrictPolicy(),
+				o:      AcceptSignatureOptions{},
+			},
+			want:        `sig1=();created`,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Key algorithm not allowed",
+			args: args{
+				policy: Policy{SignatureAlgorithms: []string{algoHmacSha256}},
+				o:      AcceptSignatureOptions{KeyID: "test-key-rsa"},
+			},
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "algorithm 'RSA-SHA256' not allowed by policy",
+		},
+		{
+			name: "Unknown key",
+			args: args{
+				policy: Policy{},
+				o:      AcceptSignatureOptions{KeyID: "unknown"},
+			},
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "keyID 'unknown' not found: SecretError: secret not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			hs.SetPolicy(tt.args.policy)
+			got, err := hs.AcceptSignature(tt.args.o)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParseAcceptSignature(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		want        []AcceptedSignature
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:   "Requested signatures",
+			header: `sig1=("@method" "@path";req "content-type");created;nonce="n";alg="hmac-sha256";keyid="k";tag="t", sig2=()`,
+			want: []AcceptedSignature{
+				{
+					KeyID:     "k",
+					Algorithm: algoHmacSha256,
+					Options: MessageSignatureOptions{
+						Label:      "sig1",
+						Components: []string{`"@method"`, `"@path";req`, `"content-type"`},
+						Nonce:      "n",
+						Tag:        "t",
+						Alg:        true,
+					},
+				},
+				{
+					Options: MessageSignatureOptions{
+						Label:      "sig2",
+						Components: []string{},
+					},
+				},
+			},
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "No header",
+			header:      "",
+			want:        nil,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "accept-signature header not found",
+		},
+		{
+			name:        "Not an inner list",
+			header:      `sig1="@method"`,
+			want:        nil,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "requested signature 'sig1' must be an inner list",
+		},
+		{
+			name:        "Unknown algorithm",
+			header:      `sig1=();alg="ecdsa-p384-sha384"`,
+			want:        nil,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "requested algorithm 'ecdsa-p384-sha384' not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			resp := &http.Response{Header: http.Header{}}
+			if len(tt.header) > 0 {
+				resp.Header.Set("Accept-Signature", tt.header)
+			}
+			got, err := hs.ParseAcceptSignature(resp)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParseAcceptSignatureExpires(t *testing.T) {
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	hs.SetDefaultExpiresPeriod(time.Minute)
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Accept-Signature", `sig1=();created;expires`)
+	got, err := hs.ParseAcceptSignature(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if e := time.Until(got[0].Options.Expires); e <= 0 || e > time.Minute {
+		t.Errorf("expires in %s, want within a minute", e)
+	}
+}
+
+func TestSignAcceptedMessage(t *testing.T) {
+	tests := []struct {
+		name         string
+		o            AcceptSignatureOptions
+		defaultKeyID string
+		want         bool
+		wantErrType  string
+		wantErrMsg   string
+	}{
+		{
+			name:        "Requested key",
+			o:           AcceptSignatureOptions{KeyID: "test-key-rsa", Tag: "app"},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:         "Default key",
+			o:            AcceptSignatureOptions{Expires: true},
+			defaultKeyID: "test-shared-secret",
+			want:         true,
+			wantErrType:  httpsignaturesErrType,
+		},
+		{
+			name:        "No key",
+			o:           AcceptSignatureOptions{},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "keyID '' not found: SecretError: secret not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			hs.SetPolicy(Policy{RequiredComponents: []string{"@method", "@path", "date"}})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := hs.VerifyMessageSignature(r); err != nil {
+					if err = hs.WriteUnauthorized(w, tt.o); err != nil {
+						t.Errorf("unexpected error: %s", err)
+					}
+				}
+			}))
+			defer srv.Close()
+
+			r, _ := http.NewRequest(http.MethodGet, srv.URL+"/foo", nil)
+			r.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+			resp, err := srv.Client().Do(r)
+			if err != nil {
+				t.Fatalf("request error: %s", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+			}
+
+			err = hs.SignAcceptedMessage(r, resp, tt.defaultKeyID)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+			if !got {
+				return
+			}
+			resp, err = srv.Client().Do(r)
+			if err != nil {
+				t.Fatalf("request error: %s", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+		})
+	}
+}
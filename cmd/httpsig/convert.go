@@ -0,0 +1,35 @@
+package main
+
+import (
+	"io"
+)
+
+func convert(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("convert", stderr)
+	to := fs.String("to", encodingPKIX, "output encoding: pkcs1, pkcs8, pkix or jwk")
+	public := fs.Bool("public", false, "output public key of the private key (pkcs1 & jwk)")
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	k, err := readKey(path, stdin)
+	if err != nil {
+		return err
+	}
+	s, err := k.encode(*to, *public)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, s)
+	return err
+}
+
+// readKey read & parse key from the file or stdin (`-`)
+func readKey(path string, stdin io.Reader) (keyMaterial, error) {
+	b, err := readInput(path, stdin)
+	if err != nil {
+		return keyMaterial{}, err
+	}
+	return parseKey(b)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "PKIX to JWK",
+			args:       []string{"-to", "jwk"},
+			stdin:      rfc8410PublicKey,
+			wantCode:   0,
+			wantStdout: `"x": "Gb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE"`,
+		},
+		{
+			name:       "JWK to PKIX",
+			stdin:      `{"kty": "OKP", "crv": "Ed25519", "x": "Gb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE"}`,
+			wantCode:   0,
+			wantStdout: rfc8410PublicKey,
+		},
+		{
+			name: "Private JWK to public JWK",
+			args: []string{"-to", "jwk", "-public"},
+			stdin: `{"kty": "OKP", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",` +
+				`"d": "nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"}`,
+			wantCode: 0,
+			wantStdout: "{\n  \"kty\": \"OKP\",\n  \"kid\": \"kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k\",\n" +
+				"  \"crv\": \"Ed25519\",\n  \"x\": \"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo\"\n}\n",
+		},
+		{
+			name:       "Wrong encoding",
+			args:       []string{"-to", "pkcs1"},
+			stdin:      rfc8410PublicKey,
+			wantCode:   1,
+			wantStderr: "httpsig convert: PKCS#1 encoding supports RSA keys only\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCmd(append([]string{"convert"}, tt.args...), tt.stdin)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d, stderr: %s", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if stderr != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestConvertSignVerify(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	req := writeFile(t, dir, "request.txt", testRequest)
+
+	tests := []struct {
+		keyType string
+		alg     string
+		private []string
+		public  [][]string
+	}{
+		{
+			keyType: keyTypeRSA,
+			alg:     "RSA-SHA256",
+			private: []string{encodingPKCS1, encodingPKCS8, encodingJWK},
+			public:  [][]string{{"-to", encodingPKIX}, {"-to", encodingPKCS1, "-public"}, {"-to", encodingJWK, "-public"}},
+		},
+		{
+			keyType: keyTypeHMAC,
+			alg:     "HMAC-SHA256",
+			private: []string{encodingJWK},
+			public:  [][]string{{"-to", encodingJWK}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keyType, func(t *testing.T) {
+			keyFile := filepath.Join(dir, tt.keyType+".key")
+			code, _, stderr := runCmd([]string{"keygen", "-type", tt.keyType, "-key-id", "k", "-out", keyFile}, "")
+			if code != 0 {
+				t.Fatalf("keygen: %s", stderr)
+			}
+
+			convert := func(args ...string) string {
+				code, stdout, stderr := runCmd(append(append([]string{"convert"}, args...), keyFile), "")
+				if code != 0 {
+					t.Fatalf("convert %v: %s", args, stderr)
+				}
+				return writeFile(t, dir, tt.keyType+"-"+strings.Join(args, ""), stdout)
+			}
+			var publicFiles []string
+			for _, args := range tt.public {
+				publicFiles = append(publicFiles, convert(args...))
+			}
+
+			// Every converted private key signs the request verified with every converted public key
+			for _, encoding := range tt.private {
+				privateFile := convert("-to", encoding)
+				code, signed, stderr := runCmd([]string{"sign", "-key", privateFile, "-key-id", "k", "-alg", tt.alg, req}, "")
+				if code != 0 {
+					t.Errorf("sign with %s key: %s", encoding, stderr)
+					continue
+				}
+				for i, publicFile := range publicFiles {
+					code, stdout, _ := runCmd([]string{"verify", "-key", publicFile, "-key-id", "k", "-alg", tt.alg, "-"}, signed)
+					if code != 0 || !strings.HasSuffix(stdout, "signature: ok\n") {
+						t.Errorf("sign with %s key, verify with %v key: %d %s", encoding, tt.public[i], code, stdout)
+					}
+				}
+			}
+		})
+	}
+}
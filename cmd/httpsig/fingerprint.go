@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func fingerprint(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("fingerprint", stderr)
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	k, err := readKey(path, stdin)
+	if err != nil {
+		return err
+	}
+	fp, err := k.fingerprint()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, fp)
+	return err
+}
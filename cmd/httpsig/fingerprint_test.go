@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// rfc8410PublicKey Ed25519 public key from RFC 8410 10.1
+const rfc8410PublicKey = "-----BEGIN PUBLIC KEY-----\n" +
+	"MCowBQYDK2VwAyEAGb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE=\n" +
+	"-----END PUBLIC KEY-----\n"
+
+func TestFingerprint(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	keyFile := writeFile(t, dir, "ed25519.pub", rfc8410PublicKey)
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "Key file",
+			args:       []string{keyFile},
+			wantCode:   0,
+			wantStdout: "3YZxBJ12jO3n_mXt_q-j_Z-TFP1vt-NiI3OCf-fEnHQ\n",
+		},
+		{
+			name:       "JWK from stdin",
+			stdin:      `{"kty": "OKP", "crv": "Ed25519", "x": "Gb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE"}`,
+			wantCode:   0,
+			wantStdout: "3YZxBJ12jO3n_mXt_q-j_Z-TFP1vt-NiI3OCf-fEnHQ\n",
+		},
+		{
+			name:       "HMAC secret",
+			stdin:      "test-secret\n",
+			wantCode:   0,
+			wantStdout: "xQKgLDLYNnBRqAx7Z5Wv2EJ-zZ27PNK_6KetxwRKfjg\n",
+		},
+		{
+			name:       "Wrong key",
+			stdin:      "-----BEGIN PUBLIC KEY-----\nAQ==\n-----END PUBLIC KEY-----\n",
+			wantCode:   1,
+			wantStderr: "httpsig fingerprint: wrong PUBLIC KEY: asn1: syntax error: truncated tag or length\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCmd(append([]string{"fingerprint"}, tt.args...), tt.stdin)
+			if code != tt.wantCode || stdout != tt.wantStdout || stderr != tt.wantStderr {
+				t.Errorf("got %d %q %q, want %d %q %q", code, stdout, stderr, tt.wantCode, tt.wantStdout, tt.wantStderr)
+			}
+		})
+	}
+}
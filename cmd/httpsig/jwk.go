@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// jwk JSON Web Key (RFC 7517) of RSA, EC, OKP (Ed25519) & oct (HMAC) key types
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	D   string `json:"d,omitempty"`
+	P   string `json:"p,omitempty"`
+	Q   string `json:"q,omitempty"`
+	DP  string `json:"dp,omitempty"`
+	DQ  string `json:"dq,omitempty"`
+	QI  string `json:"qi,omitempty"`
+	K   string `json:"k,omitempty"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// curves JWK curve names of the supported ECDSA curves
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// newJWK JWK of the key, private members are set for private keys unless public is set
+func newJWK(k keyMaterial, public bool) (jwk, error) {
+	var j jwk
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		j = jwk{Kty: "RSA", N: b64.EncodeToString(pub.N.Bytes()), E: b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())}
+		if priv, ok := k.private.(*rsa.PrivateKey); ok && !public {
+			if len(priv.Primes) != 2 {
+				return jwk{}, errors.New("multi-prime RSA keys are not supported")
+			}
+			priv.Precompute()
+			j.D = b64.EncodeToString(priv.D.Bytes())
+			j.P = b64.EncodeToString(priv.Primes[0].Bytes())
+			j.Q = b64.EncodeToString(priv.Primes[1].Bytes())
+			j.DP = b64.EncodeToString(priv.Precomputed.Dp.Bytes())
+			j.DQ = b64.EncodeToString(priv.Precomputed.Dq.Bytes())
+			j.QI = b64.EncodeToString(priv.Precomputed.Qinv.Bytes())
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		j = jwk{
+			Kty: "EC",
+			Crv: pub.Curve.Params().Name,
+			X:   b64.EncodeToString(padded(pub.X, size)),
+			Y:   b64.EncodeToString(padded(pub.Y, size)),
+		}
+		if _, ok := curves[j.Crv]; !ok {
+			return jwk{}, fmt.Errorf("unsupported curve '%s'", j.Crv)
+		}
+		if priv, ok := k.private.(*ecdsa.PrivateKey); ok && !public {
+			j.D = b64.EncodeToString(padded(priv.D, size))
+		}
+	case ed25519.PublicKey:
+		j = jwk{Kty: "OKP", Crv: "Ed25519", X: b64.EncodeToString(pub)}
+		if priv, ok := k.private.(ed25519.PrivateKey); ok && !public {
+			j.D = b64.EncodeToString(priv.Seed())
+		}
+	case nil:
+		if public {
+			return jwk{}, errors.New("HMAC secret has no public key")
+		}
+		j = jwk{Kty: "oct", K: b64.EncodeToString([]byte(k.secret))}
+	default:
+		return jwk{}, fmt.Errorf("unsupported key type %T", pub)
+	}
+	return j, nil
+}
+
+// key key material of the JWK
+func (j jwk) key() (keyMaterial, error) {
+	switch j.Kty {
+	case "RSA":
+		return j.rsaKey()
+	case "EC":
+		return j.ecKey()
+	case "OKP":
+		return j.ed25519Key()
+	case "oct":
+		k, err := b64.DecodeString(j.K)
+		if err != nil || len(k) == 0 {
+			return keyMaterial{}, errors.New("wrong JWK 'k' member")
+		}
+		return keyMaterial{secret: string(k)}, nil
+	default:
+		return keyMaterial{}, fmt.Errorf("unsupported JWK key type '%s'", j.Kty)
+	}
+}
+
+func (j jwk) rsaKey() (keyMaterial, error) {
+	n, err := decodeInt(j.N, "n")
+	if err != nil {
+		return keyMaterial{}, err
+	}
+	e, err := decodeInt(j.E, "e")
+	if err != nil {
+		return keyMaterial{}, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return keyMaterial{}, errors.New("wrong JWK 'e' member")
+	}
+	pub := &rsa.PublicKey{N: n, E: int(e.Int64())}
+	if len(j.D) == 0 {
+		return keyMaterial{public: pub}, nil
+	}
+
+	priv := &rsa.PrivateKey{PublicKey: *pub}
+	if priv.D, err = decodeInt(j.D, "d"); err != nil {
+		return keyMaterial{}, err
+	}
+	p, err := decodeInt(j.P, "p")
+	if err != nil {
+		return keyMaterial{}, err
+	}
+	q, err := decodeInt(j.Q, "q")
+	if err != nil {
+		return keyMaterial{}, err
+	}
+	priv.Primes = []*big.Int{p, q}
+	if err = priv.Validate(); err != nil {
+		return keyMaterial{}, fmt.Errorf("wrong JWK RSA private key: %w", err)
+	}
+	priv.Precompute()
+	return keyMaterial{private: priv, public: &priv.PublicKey}, nil
+}
+
+func (j jwk) ecKey() (keyMaterial, error) {
+	curve, ok := curves[j.Crv]
+	if !ok {
+		return keyMaterial{}, fmt.Errorf("unsupported curve '%s'", j.Crv)
+	}
+	x, err := decodeInt(j.X, "x")
+	if err != nil {
+		return keyMaterial{}, err
+	}
+	y, err := decodeInt(j.Y, "y")
+	if err != nil {
+		return keyMaterial{}, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return keyMaterial{}, errors.New("JWK EC public key is not on the curve")
+	}
+	pub := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if len(j.D) == 0 {
+		return keyMaterial{public: pub}, nil
+	}
+
+	d, err := decodeInt(j.D, "d")
+	if err != nil {
+		return keyMaterial{}, err
+	}
+	if d.Cmp(curve.Params().N) >= 0 {
+		return keyMaterial{}, errors.New("wrong JWK 'd' member")
+	}
+	px, py := curve.ScalarBaseMult(d.Bytes())
+	if px.Cmp(x) != 0 || py.Cmp(y) != 0 {
+		return keyMaterial{}, errors.New("JWK EC private key doesn't match the public key")
+	}
+	priv := &ecdsa.PrivateKey{PublicKey: *pub, D: d}
+	return keyMaterial{private: priv, public: &priv.PublicKey}, nil
+}
+
+func (j jwk) ed25519Key() (keyMaterial, error) {
+	if j.Crv != "Ed25519" {
+		return keyMaterial{}, fmt.Errorf("unsupported curve '%s'", j.Crv)
+	}
+	x, err := b64.DecodeString(j.X)
+	if err != nil || len(x) != ed25519.PublicKeySize {
+		return keyMaterial{}, errors.New("wrong JWK 'x' member")
+	}
+	if len(j.D) == 0 {
+		return keyMaterial{public: ed25519.PublicKey(x)}, nil
+	}
+
+	d, err := b64.DecodeString(j.D)
+	if err != nil || len(d) != ed25519.SeedSize {
+		return keyMaterial{}, errors.New("wrong JWK 'd' member")
+	}
+	priv := ed25519.NewKeyFromSeed(d)
+	if !bytes.Equal(priv.Public().(ed25519.PublicKey), x) {
+		return keyMaterial{}, errors.New("JWK Ed25519 private key doesn't match the public key")
+	}
+	return keyMaterial{private: priv, public: priv.Public()}, nil
+}
+
+// thumbprint JWK SHA-256 thumbprint (RFC 7638): hash of the required public members in lexicographic order
+func (j jwk) thumbprint() string {
+	var members string
+	switch j.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, j.E, j.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, j.Crv, j.X, j.Y)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, j.Crv, j.X)
+	case "oct":
+		members = fmt.Sprintf(`{"k":"%s","kty":"oct"}`, j.K)
+	}
+	h := sha256.Sum256([]byte(members))
+	return b64.EncodeToString(h[:])
+}
+
+// marshal indented JWK JSON
+func (j jwk) marshal() (string, error) {
+	b, err := json.MarshalIndent(j, "", "  ")
+	return string(b) + "\n", err
+}
+
+func decodeInt(s string, name string) (*big.Int, error) {
+	b, err := b64.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("wrong JWK '%s' member", name)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// padded big-endian bytes of the integer left-padded with zeros to the size
+func padded(i *big.Int, size int) []byte {
+	b := i.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
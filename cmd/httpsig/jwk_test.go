@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// rfc7638Key RSA public key from RFC 7638 3.1
+var rfc7638Key = jwk{
+	Kty: "RSA",
+	N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMs" +
+		"tn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1" +
+		"n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	E: "AQAB",
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	tests := []struct {
+		name string
+		jwk  jwk
+		want string
+	}{
+		{
+			name: "RFC 7638 RSA key",
+			jwk:  rfc7638Key,
+			want: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		},
+		{
+			name: "RFC 8037 Ed25519 key",
+			jwk:  jwk{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+			want: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k",
+		},
+		{
+			name: "Private members are ignored",
+			jwk:  jwk{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo", D: "nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"},
+			want: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.jwk.thumbprint(); got != tt.want {
+				t.Errorf("thumbprint = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWKKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		jwk        jwk
+		wantErrMsg string
+	}{
+		{
+			name: "RSA public key",
+			jwk:  rfc7638Key,
+		},
+		{
+			name: "RFC 8037 Ed25519 private key",
+			jwk:  jwk{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo", D: "nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"},
+		},
+		{
+			name: "HMAC secret",
+			jwk:  jwk{Kty: "oct", K: "c2VjcmV0"},
+		},
+		{
+			name:       "Ed25519 private key doesn't match",
+			jwk:        jwk{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo", D: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"},
+			wantErrMsg: "JWK Ed25519 private key doesn't match the public key",
+		},
+		{
+			name:       "EC key not on curve",
+			jwk:        jwk{Kty: "EC", Crv: "P-256", X: "AQ", Y: "AQ"},
+			wantErrMsg: "JWK EC public key is not on the curve",
+		},
+		{
+			name:       "Unsupported curve",
+			jwk:        jwk{Kty: "EC", Crv: "P-224", X: "AQ", Y: "AQ"},
+			wantErrMsg: "unsupported curve 'P-224'",
+		},
+		{
+			name:       "RSA private key without primes",
+			jwk:        jwk{Kty: "RSA", N: rfc7638Key.N, E: "AQAB", D: "AQ"},
+			wantErrMsg: "wrong JWK 'p' member",
+		},
+		{
+			name:       "Wrong member encoding",
+			jwk:        jwk{Kty: "RSA", N: "n+/", E: "AQAB"},
+			wantErrMsg: "wrong JWK 'n' member",
+		},
+		{
+			name:       "Empty HMAC secret",
+			jwk:        jwk{Kty: "oct"},
+			wantErrMsg: "wrong JWK 'k' member",
+		},
+		{
+			name:       "Unsupported key type",
+			jwk:        jwk{Kty: "OKP", Crv: "X25519", X: "AQ"},
+			wantErrMsg: "unsupported curve 'X25519'",
+		},
+		{
+			name:       "Unknown key type",
+			jwk:        jwk{Kty: "DSA"},
+			wantErrMsg: "unsupported JWK key type 'DSA'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := tt.jwk.key()
+			if err != nil || len(tt.wantErrMsg) > 0 {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("error = %v, want %s", err, tt.wantErrMsg)
+				}
+				return
+			}
+			// Key is encoded back into the same JWK
+			got, err := newJWK(k, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.jwk) {
+				t.Errorf("got  = %v,\nwant = %v", got, tt.jwk)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Key encodings of the convert command
+const (
+	encodingPKCS1 = "pkcs1"
+	encodingPKCS8 = "pkcs8"
+	encodingPKIX  = "pkix"
+	encodingJWK   = "jwk"
+)
+
+// keyMaterial parsed key: key pair (private is nil for public keys) or HMAC secret
+type keyMaterial struct {
+	private crypto.Signer
+	public  crypto.PublicKey
+	secret  string
+}
+
+// parseKey parse PEM (PKCS#1, PKCS#8, SEC 1 or PKIX) or JWK key, any other content is taken as HMAC secret
+// the same way as -key file is loaded
+func parseKey(b []byte) (keyMaterial, error) {
+	trimmed := bytes.TrimSpace(b)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN ")):
+		return parsePEM(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var j jwk
+		if err := json.Unmarshal(trimmed, &j); err != nil {
+			return keyMaterial{}, fmt.Errorf("wrong JWK: %w", err)
+		}
+		return j.key()
+	}
+	secret := strings.TrimRight(string(b), "\r\n")
+	if len(secret) == 0 {
+		return keyMaterial{}, errors.New("empty key")
+	}
+	return keyMaterial{secret: secret}, nil
+}
+
+func parsePEM(b []byte) (keyMaterial, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return keyMaterial{}, errors.New("wrong PEM block")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return keyMaterial{}, fmt.Errorf("unsupported PEM block type '%s'", block.Type)
+	}
+	if err != nil {
+		return keyMaterial{}, fmt.Errorf("wrong %s: %w", block.Type, err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		s := k.(crypto.Signer)
+		return keyMaterial{private: s, public: s.Public()}, nil
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return keyMaterial{public: k}, nil
+	default:
+		return keyMaterial{}, fmt.Errorf("unsupported key type %T", k)
+	}
+}
+
+// encode key in PEM encoding or as JWK, public key is encoded if public is set or encoding is PKIX
+func (k keyMaterial) encode(encoding string, public bool) (string, error) {
+	if k.public == nil && encoding != encodingJWK {
+		return "", fmt.Errorf("HMAC secret can't be encoded as %s", encoding)
+	}
+	if k.private == nil && encoding == encodingPKCS8 {
+		return "", errors.New("PKCS#8 encoding requires a private key")
+	}
+
+	var block pem.Block
+	var err error
+	switch encoding {
+	case encodingPKCS1:
+		priv, ok := k.private.(*rsa.PrivateKey)
+		pub, pubOK := k.public.(*rsa.PublicKey)
+		switch {
+		case ok && !public:
+			block = pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)}
+		case pubOK:
+			block = pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(pub)}
+		default:
+			return "", errors.New("PKCS#1 encoding supports RSA keys only")
+		}
+	case encodingPKCS8:
+		if public {
+			return "", errors.New("PKCS#8 encoding is defined for private keys only")
+		}
+		block.Type = "PRIVATE KEY"
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(k.private)
+	case encodingPKIX:
+		block.Type = "PUBLIC KEY"
+		block.Bytes, err = x509.MarshalPKIXPublicKey(k.public)
+	case encodingJWK:
+		var j jwk
+		if j, err = newJWK(k, public); err != nil {
+			return "", err
+		}
+		j.Kid = j.thumbprint()
+		return j.marshal()
+	default:
+		return "", fmt.Errorf("unknown key encoding '%s'", encoding)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&block)), nil
+}
+
+// fingerprint JWK SHA-256 thumbprint of the public key (or HMAC secret), used as default keyId
+func (k keyMaterial) fingerprint() (string, error) {
+	j, err := newJWK(k, k.public != nil)
+	if err != nil {
+		return "", err
+	}
+	return j.thumbprint(), nil
+}
+
+// algorithm default algorithm name of the key generated by keygen
+func (k keyMaterial) algorithm() string {
+	if _, ok := k.public.(*rsa.PublicKey); ok {
+		return "RSA-SHA256"
+	}
+	return "HMAC-SHA256"
+}
+
+// manifestSecret secret loadable by the -key file or secrets manifest: private key is encoded with PKCS#8,
+// public key with PKIX
+func (k keyMaterial) manifestSecret(keyID, alg string) (manifestSecret, error) {
+	s := manifestSecret{KeyID: keyID, Algorithm: alg}
+	if k.public == nil {
+		s.PrivateKey = k.secret
+		return s, nil
+	}
+
+	var err error
+	if s.PublicKey, err = k.encode(encodingPKIX, true); err != nil {
+		return manifestSecret{}, err
+	}
+	if k.private == nil {
+		return s, nil
+	}
+	if s.PrivateKey, err = k.encode(encodingPKCS8, false); err != nil {
+		return manifestSecret{}, err
+	}
+	return s, nil
+}
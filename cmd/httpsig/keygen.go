@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// Key types of the keygen command: keys of the signature algorithms implemented by the library
+const (
+	keyTypeRSA  = "rsa"
+	keyTypeHMAC = "hmac"
+)
+
+func keygen(args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("keygen", stderr)
+	keyType := fs.String("type", keyTypeRSA, "key type: rsa or hmac")
+	bits := fs.Int("bits", 0, "RSA key size: 2048, 3072 or 4096 (default 2048), HMAC secret size (default 256)")
+	keyID := fs.String("key-id", "", "keyId of the secret (default: JWK thumbprint of the key)")
+	alg := fs.String("alg", "", "algorithm of the secret (default: RSA-SHA256 or HMAC-SHA256)")
+	out := fs.String("out", "", "write key to the `file` (public key to file.pub) loadable with -key")
+	manifest := fs.String("manifest", "", "add secret to the secrets manifest `file`, it's created if not exists")
+	if err := fs.Parse(args); err != nil {
+		return parseFlagsError(err)
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments %v\n", fs.Args())
+		return errUsage
+	}
+
+	k, err := generateKey(*keyType, *bits)
+	if err != nil {
+		return err
+	}
+	if len(*keyID) == 0 {
+		if *keyID, err = k.fingerprint(); err != nil {
+			return err
+		}
+	}
+	if len(*alg) == 0 {
+		*alg = k.algorithm()
+	}
+	s, err := k.manifestSecret(*keyID, *alg)
+	if err != nil {
+		return err
+	}
+
+	if len(*out) > 0 {
+		if err = writeKeyFiles(*out, s); err != nil {
+			return err
+		}
+	}
+	if len(*manifest) > 0 {
+		if err = addToManifest(*manifest, s); err != nil {
+			return err
+		}
+	}
+	if len(*out) > 0 || len(*manifest) > 0 {
+		fmt.Fprintf(stdout, "keyId: %s\nalgorithm: %s\n", s.KeyID, s.Algorithm)
+		return nil
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "%s\n", b)
+	return err
+}
+
+// generateKey generate RSA key pair or random HMAC secret. HMAC secret is base64url encoded random bytes,
+// so it could be stored in the text key file & manifest as is
+func generateKey(keyType string, bits int) (keyMaterial, error) {
+	switch keyType {
+	case keyTypeRSA:
+		if bits == 0 {
+			bits = 2048
+		}
+		if bits != 2048 && bits != 3072 && bits != 4096 {
+			return keyMaterial{}, fmt.Errorf("wrong RSA key size %d, supported: 2048, 3072, 4096", bits)
+		}
+		k, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return keyMaterial{}, err
+		}
+		return keyMaterial{private: k, public: &k.PublicKey}, nil
+	case keyTypeHMAC:
+		if bits == 0 {
+			bits = 256
+		}
+		if bits < 128 || bits%8 != 0 {
+			return keyMaterial{}, fmt.Errorf("wrong HMAC secret size %d, at least 128 bits (multiple of 8) required", bits)
+		}
+		b := make([]byte, bits/8)
+		if _, err := rand.Read(b); err != nil {
+			return keyMaterial{}, err
+		}
+		return keyMaterial{secret: b64.EncodeToString(b)}, nil
+	default:
+		return keyMaterial{}, fmt.Errorf("unsupported key type '%s', supported: rsa, hmac", keyType)
+	}
+}
+
+// writeKeyFiles write private key (HMAC secret) to the file & public key to the file.pub
+func writeKeyFiles(path string, s manifestSecret) error {
+	if err := ioutil.WriteFile(path, []byte(s.PrivateKey), 0600); err != nil {
+		return err
+	}
+	if len(s.PublicKey) == 0 {
+		return nil
+	}
+	return ioutil.WriteFile(path+".pub", []byte(s.PublicKey), 0644)
+}
+
+// addToManifest add secret to the secrets manifest, keyId must be unique
+func addToManifest(path string, s manifestSecret) error {
+	var list []manifestSecret
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	default:
+		if err = json.Unmarshal(b, &list); err != nil {
+			return fmt.Errorf("wrong secrets manifest '%s': %w", path, err)
+		}
+	}
+	for _, m := range list {
+		if m.KeyID == s.KeyID {
+			return fmt.Errorf("secrets manifest '%s' already contains keyId '%s'", path, s.KeyID)
+		}
+	}
+
+	b, err = json.MarshalIndent(append(list, s), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0600)
+}
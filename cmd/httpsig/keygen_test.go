@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeygen(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantAlg    string
+		wantPublic bool
+		wantErrMsg string
+	}{
+		{name: "RSA", args: []string{"-type", "rsa"}, wantAlg: "RSA-SHA256", wantPublic: true},
+		{name: "RSA 3072", args: []string{"-type", "rsa", "-bits", "3072"}, wantAlg: "RSA-SHA256", wantPublic: true},
+		{name: "HMAC", args: []string{"-type", "hmac"}, wantAlg: "HMAC-SHA256"},
+		{name: "HMAC-SHA512", args: []string{"-type", "hmac", "-bits", "512", "-alg", "HMAC-SHA512"}, wantAlg: "HMAC-SHA512"},
+		{
+			name:       "Wrong RSA key size",
+			args:       []string{"-type", "rsa", "-bits", "1024"},
+			wantErrMsg: "httpsig keygen: wrong RSA key size 1024, supported: 2048, 3072, 4096\n",
+		},
+		{
+			name:       "Short HMAC secret",
+			args:       []string{"-type", "hmac", "-bits", "64"},
+			wantErrMsg: "httpsig keygen: wrong HMAC secret size 64, at least 128 bits (multiple of 8) required\n",
+		},
+		{
+			name:       "Unsupported key type",
+			args:       []string{"-type", "ecdsa"},
+			wantErrMsg: "httpsig keygen: unsupported key type 'ecdsa', supported: rsa, hmac\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCmd(append([]string{"keygen"}, tt.args...), "")
+			if len(tt.wantErrMsg) > 0 {
+				if code != 1 || stderr != tt.wantErrMsg {
+					t.Errorf("got %d %q, want 1 %q", code, stderr, tt.wantErrMsg)
+				}
+				return
+			}
+			if code != 0 {
+				t.Fatalf("exit code = %d, stderr: %s", code, stderr)
+			}
+
+			var s manifestSecret
+			if err := json.Unmarshal([]byte(stdout), &s); err != nil {
+				t.Fatalf("wrong secret %q: %s", stdout, err)
+			}
+			check(t, s.Algorithm == tt.wantAlg, "algorithm = %s, want %s", s.Algorithm, tt.wantAlg)
+			check(t, len(s.PrivateKey) > 0, "private key expected")
+			check(t, (len(s.PublicKey) > 0) == tt.wantPublic, "public key = %q", s.PublicKey)
+
+			// keyId is the fingerprint of the public key
+			k, err := parseKey([]byte(s.PrivateKey))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fp, _ := k.fingerprint()
+			check(t, s.KeyID == fp, "keyId = %s, want %s", s.KeyID, fp)
+		})
+	}
+}
+
+func TestKeygenSignVerify(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	manifest := filepath.Join(dir, "keys.json")
+	req := writeFile(t, dir, "request.txt", testRequest)
+
+	// Every key type generated by keygen is supported by the library
+	keys := []struct {
+		keyID string
+		alg   string
+		args  []string
+	}{
+		{keyID: "rsa", alg: "RSA-SHA256", args: []string{"-type", "rsa"}},
+		{keyID: "hmac", alg: "HMAC-SHA256", args: []string{"-type", "hmac"}},
+		{keyID: "hmac-sha512", alg: "HMAC-SHA512", args: []string{"-type", "hmac", "-bits", "512", "-alg", "HMAC-SHA512"}},
+	}
+	for _, k := range keys {
+		keyFile := filepath.Join(dir, k.keyID+".key")
+		args := append([]string{"keygen", "-key-id", k.keyID, "-manifest", manifest, "-out", keyFile}, k.args...)
+		code, stdout, stderr := runCmd(args, "")
+		if code != 0 || stdout != "keyId: "+k.keyID+"\nalgorithm: "+k.alg+"\n" {
+			t.Fatalf("%v: %d %q %q", args, code, stdout, stderr)
+		}
+	}
+	code, _, stderr := runCmd([]string{"keygen", "-type", "hmac", "-key-id", "hmac", "-manifest", manifest}, "")
+	if code != 1 || !strings.HasSuffix(stderr, "already contains keyId 'hmac'\n") {
+		t.Errorf("duplicate keyId: %d %q", code, stderr)
+	}
+
+	// Generated keys are loaded from the manifest & key files to sign & verify the request in every format,
+	// public key is derived from the private key file
+	for _, k := range keys {
+		keyFile := filepath.Join(dir, k.keyID+".key")
+		verifyKeys := [][]string{{"-keys", manifest}, {"-key", keyFile, "-key-id", k.keyID, "-alg", k.alg}}
+		if !strings.HasPrefix(k.alg, "HMAC") {
+			verifyKeys = append(verifyKeys, []string{"-key", keyFile + ".pub", "-key-id", k.keyID, "-alg", k.alg})
+		}
+		for _, format := range []string{formatSignature, formatAuthorization, formatRFC9421} {
+			for _, sign := range [][]string{
+				{"-keys", manifest, "-key-id", k.keyID},
+				{"-key", keyFile, "-key-id", k.keyID, "-alg", k.alg},
+			} {
+				code, signed, stderr := runCmd(append(append([]string{"sign", "-format", format}, sign...), req), "")
+				if code != 0 {
+					t.Errorf("%s: sign %v: %s", format, sign, stderr)
+					continue
+				}
+				for _, verify := range verifyKeys {
+					code, stdout, _ := runCmd(append(append([]string{"verify"}, verify...), "-"), signed)
+					if code != 0 || !strings.HasSuffix(stdout, "signature: ok\n") {
+						t.Errorf("%s: sign %v, verify %v: %d %s", format, sign, verify, code, stdout)
+					}
+				}
+			}
+		}
+	}
+
+	b, err := ioutil.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []manifestSecret
+	if err = json.Unmarshal(b, &list); err != nil || len(list) != len(keys) {
+		t.Errorf("wrong manifest %s: %v", b, err)
+	}
+}
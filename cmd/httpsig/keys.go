@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"httpsignatures"
+)
+
+// manifestSecret secret in the JSON secrets manifest, e.g.
+//
+//	[{"keyId": "key1", "algorithm": "RSA-SHA256", "publicKey": "-----BEGIN PUBLIC KEY-----\n..."}]
+type manifestSecret struct {
+	KeyID      string            `json:"keyId"`
+	Algorithm  string            `json:"algorithm"`
+	PublicKey  string            `json:"publicKey,omitempty"`
+	PrivateKey string            `json:"privateKey,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// keyFlags key options shared by commands: single key file or secrets manifest
+type keyFlags struct {
+	keyFile  string
+	keyID    string
+	alg      string
+	manifest string
+}
+
+func (kf *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&kf.keyFile, "key", "", "key `file`: PEM or JWK private key, public key (verify only) or HMAC secret")
+	fs.StringVar(&kf.keyID, "key-id", "", "keyId of the key file")
+	fs.StringVar(&kf.alg, "alg", "", "algorithm of the key file, e.g. RSA-SHA256, HMAC-SHA256")
+	fs.StringVar(&kf.manifest, "keys", "", "secrets manifest `file` (JSON array of keyId, algorithm, publicKey, privateKey)")
+}
+
+// secrets load secrets from the key file or manifest
+func (kf *keyFlags) secrets() (map[string]httpsignatures.Secret, error) {
+	switch {
+	case len(kf.keyFile) > 0 && len(kf.manifest) > 0:
+		return nil, errors.New("-key & -keys options are mutually exclusive")
+	case len(kf.keyFile) > 0:
+		if len(kf.keyID) == 0 || len(kf.alg) == 0 {
+			return nil, errors.New("-key-id & -alg are required for -key")
+		}
+		b, err := ioutil.ReadFile(kf.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		s, err := keyFileSecret(b, kf.keyID, kf.alg)
+		if err != nil {
+			return nil, fmt.Errorf("wrong key file '%s': %w", kf.keyFile, err)
+		}
+		return map[string]httpsignatures.Secret{kf.keyID: s}, nil
+	case len(kf.manifest) > 0:
+		return loadManifest(kf.manifest)
+	default:
+		return nil, errors.New("-key or -keys option is required")
+	}
+}
+
+// keyFileSecret secret of the key file: PEM or JWK key is converted to PEM private key with the public key
+// derived from it (public key only for public key files), any other content is HMAC secret
+func keyFileSecret(b []byte, keyID, alg string) (httpsignatures.Secret, error) {
+	k, err := parseKey(b)
+	if err != nil {
+		return httpsignatures.Secret{}, err
+	}
+	s := httpsignatures.Secret{KeyID: keyID, Algorithm: alg}
+	if k.public == nil {
+		s.PublicKey, s.PrivateKey = k.secret, k.secret
+		return s, nil
+	}
+	m, err := k.manifestSecret(keyID, alg)
+	if err != nil {
+		return httpsignatures.Secret{}, err
+	}
+	s.PublicKey, s.PrivateKey = m.PublicKey, m.PrivateKey
+	return s, nil
+}
+
+// loadManifest load secrets from the JSON secrets manifest
+func loadManifest(path string) (map[string]httpsignatures.Secret, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []manifestSecret
+	if err = json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("wrong secrets manifest '%s': %w", path, err)
+	}
+	secrets := make(map[string]httpsignatures.Secret, len(list))
+	for i, s := range list {
+		if len(s.KeyID) == 0 || len(s.Algorithm) == 0 {
+			return nil, fmt.Errorf("wrong secrets manifest '%s': keyId & algorithm required for secret %d", path, i)
+		}
+		if _, ok := secrets[s.KeyID]; ok {
+			return nil, fmt.Errorf("wrong secrets manifest '%s': duplicate keyId '%s'", path, s.KeyID)
+		}
+		secrets[s.KeyID] = httpsignatures.Secret{
+			KeyID:      s.KeyID,
+			Algorithm:  s.Algorithm,
+			PublicKey:  s.PublicKey,
+			PrivateKey: s.PrivateKey,
+			Metadata:   s.Metadata,
+		}
+	}
+	return secrets, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"httpsignatures"
+)
+
+func TestKeyFlagsSecrets(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	keyFile := writeFile(t, dir, "hmac.key", "test-secret\r\n")
+	manifest := writeFile(t, dir, "keys.json", `[
+		{"keyId": "k1", "algorithm": "RSA-SHA256", "publicKey": "pub", "metadata": {"owner": "test"}},
+		{"keyId": "k2", "algorithm": "HMAC-SHA256", "privateKey": "test-secret"}
+	]`)
+
+	tests := []struct {
+		name       string
+		kf         keyFlags
+		want       map[string]httpsignatures.Secret
+		wantErrMsg string
+	}{
+		{
+			name: "Key file",
+			kf:   keyFlags{keyFile: keyFile, keyID: "k1", alg: "HMAC-SHA256"},
+			want: map[string]httpsignatures.Secret{
+				"k1": {KeyID: "k1", Algorithm: "HMAC-SHA256", PublicKey: "test-secret", PrivateKey: "test-secret"},
+			},
+		},
+		{
+			name: "Manifest",
+			kf:   keyFlags{manifest: manifest},
+			want: map[string]httpsignatures.Secret{
+				"k1": {KeyID: "k1", Algorithm: "RSA-SHA256", PublicKey: "pub", Metadata: map[string]string{"owner": "test"}},
+				"k2": {KeyID: "k2", Algorithm: "HMAC-SHA256", PrivateKey: "test-secret"},
+			},
+		},
+		{
+			name:       "No keys",
+			kf:         keyFlags{},
+			wantErrMsg: "-key or -keys option is required",
+		},
+		{
+			name:       "Both key & manifest",
+			kf:         keyFlags{keyFile: keyFile, manifest: manifest},
+			wantErrMsg: "-key & -keys options are mutually exclusive",
+		},
+		{
+			name:       "Key file without algorithm",
+			kf:         keyFlags{keyFile: keyFile, keyID: "k1"},
+			wantErrMsg: "-key-id & -alg are required for -key",
+		},
+		{
+			name: "Wrong manifest",
+			kf:   keyFlags{manifest: writeFile(t, dir, "wrong.json", `{}`)},
+			wantErrMsg: "wrong secrets manifest '" + filepath.Join(dir, "wrong.json") +
+				"': json: cannot unmarshal object into Go value of type []main.manifestSecret",
+		},
+		{
+			name:       "Manifest secret without algorithm",
+			kf:         keyFlags{manifest: writeFile(t, dir, "noalg.json", `[{"keyId": "k1"}]`)},
+			wantErrMsg: "wrong secrets manifest '" + filepath.Join(dir, "noalg.json") + "': keyId & algorithm required for secret 0",
+		},
+		{
+			name: "Duplicate keyId",
+			kf: keyFlags{manifest: writeFile(t, dir, "dup.json",
+				`[{"keyId": "k1", "algorithm": "a"}, {"keyId": "k1", "algorithm": "b"}]`)},
+			wantErrMsg: "wrong secrets manifest '" + filepath.Join(dir, "dup.json") + "': duplicate keyId 'k1'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.kf.secrets()
+			if err != nil && err.Error() != tt.wantErrMsg || err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf("error = %v, want %s", err, tt.wantErrMsg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  = %v,\nwant = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyFileSecret(t *testing.T) {
+	k, err := generateKey(keyTypeRSA, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1, _ := k.encode(encodingPKCS1, false)
+	pkix, _ := k.encode(encodingPKIX, true)
+	pkcs8, _ := k.encode(encodingPKCS8, false)
+	rsaPublic, _ := k.encode(encodingPKCS1, true)
+	jwkPublic, _ := k.encode(encodingJWK, true)
+
+	tests := []struct {
+		name        string
+		key         string
+		wantPublic  string
+		wantPrivate string
+		wantErrMsg  string
+	}{
+		{name: "PKCS#1 private key", key: pkcs1, wantPublic: pkix, wantPrivate: pkcs8},
+		{name: "PKCS#8 private key", key: pkcs8, wantPublic: pkix, wantPrivate: pkcs8},
+		{name: "PKIX public key", key: pkix, wantPublic: pkix},
+		{name: "PKCS#1 public key", key: rsaPublic, wantPublic: pkix},
+		{name: "JWK public key", key: jwkPublic, wantPublic: pkix},
+		{name: "HMAC secret", key: "test-secret\n", wantPublic: "test-secret", wantPrivate: "test-secret"},
+		{
+			name:        "JWK HMAC secret",
+			key:         `{"kty": "oct", "k": "dGVzdC1zZWNyZXQ"}`,
+			wantPublic:  "test-secret",
+			wantPrivate: "test-secret",
+		},
+		{name: "Wrong PEM", key: "-----BEGIN CERTIFICATE-----\n", wantErrMsg: "wrong PEM block"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keyFileSecret([]byte(tt.key), "k1", "RSA-SHA256")
+			if err != nil && err.Error() != tt.wantErrMsg || err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf("error = %v, want %s", err, tt.wantErrMsg)
+			}
+			if err != nil {
+				return
+			}
+			check(t, got.PublicKey == tt.wantPublic, "public key = %q, want %q", got.PublicKey, tt.wantPublic)
+			check(t, got.PrivateKey == tt.wantPrivate, "private key = %q, want %q", got.PrivateKey, tt.wantPrivate)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Command httpsig signs & verifies raw HTTP requests with draft-cavage or RFC 9421 signatures.
+//
+// Usage:
+//
+//	httpsig sign -key key.pem -key-id id -alg RSA-SHA256 [options] [request.txt]
+//	httpsig verify -keys keys.json [options] [request.txt]
+//	httpsig keygen -type rsa|hmac [options]
+//	httpsig convert -to pkcs1|pkcs8|pkix|jwk [-public] [key.pem]
+//	httpsig fingerprint [key.pem]
+//
+// Raw request is read from the file or stdin (`-`), e.g.
+//
+//	POST /foo?param=value HTTP/1.1
+//	Host: example.com
+//	Date: Tue, 20 Apr 2021 02:07:55 GMT
+//	Content-Type: application/json
+//	Content-Length: 18
+//
+//	{"hello": "world"}
+//
+// Exit code is 1 if signing or verification failed, 2 for wrong usage.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const usage = `Usage: httpsig <command> [options] [request file]
+
+Commands:
+  sign         sign raw HTTP request, print signed request or curl command
+  verify       verify signature of raw HTTP request, print signing string & checks
+  keygen       generate RSA key pair or HMAC secret as secret JSON, key files or secrets manifest entry
+  convert      convert key between PEM PKCS#1, PKCS#8, PKIX & JWK
+  fingerprint  print JWK thumbprint of the key, used as default keyId
+
+Run 'httpsig <command> -h' for command options.
+`
+
+// errUsage wrong command line arguments, details are already printed by the flag set
+var errUsage = errors.New("wrong usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "sign":
+		err = sign(args[1:], stdin, stdout, stderr)
+	case "verify":
+		err = verify(args[1:], stdin, stdout, stderr)
+	case "keygen":
+		err = keygen(args[1:], stdin, stdout, stderr)
+	case "convert":
+		err = convert(args[1:], stdin, stdout, stderr)
+	case "fingerprint":
+		err = fingerprint(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command '%s'\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if errors.Is(err, errUsage) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "httpsig %s: %s\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// newFlagSet flag set of the command, errors & usage are written to stderr
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("httpsig "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags parse command flags, the only positional argument is an input file (stdin by default)
+func parseFlags(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", parseFlagsError(err)
+	}
+	switch fs.NArg() {
+	case 0:
+		return "-", nil
+	case 1:
+		return fs.Arg(0), nil
+	default:
+		fmt.Fprintf(fs.Output(), "only one input file expected, got %d\n", fs.NArg())
+		return "", errUsage
+	}
+}
+
+// parseFlagsError flag.ErrHelp is returned for -h option, any other error is already printed by the flag set
+func parseFlagsError(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return errUsage
+}
+
+// readInput read the file or stdin (`-`)
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// readRequest read raw HTTP request from the file or stdin (`-`). Scheme is not sent in the request line,
+// so it's set from the command options
+func readRequest(path string, stdin io.Reader, scheme string) (*http.Request, error) {
+	b, err := readInput(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(b)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading request: %w", err)
+	}
+	if err = bufferBody(r); err != nil {
+		return nil, err
+	}
+	r.URL.Scheme = scheme
+	r.URL.Host = r.Host
+	return r, nil
+}
+
+// bufferBody read request body, so it could be read again for the digest, signature & output
+func bufferBody(r *http.Request) error {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("error reading request body: %w", err)
+	}
+	if err = r.Body.Close(); err != nil {
+		return err
+	}
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(string(b))), nil
+	}
+	r.Body, _ = r.GetBody()
+	return nil
+}
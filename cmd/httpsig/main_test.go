@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testRequest = "POST /foo?param=Value&Pet=dog HTTP/1.1\r\n" +
+	"Host: example.com\r\n" +
+	"Date: Tue, 20 Apr 2021 02:07:55 GMT\r\n" +
+	"Content-Type: application/json\r\n" +
+	"Content-Length: 18\r\n" +
+	"\r\n" +
+	`{"hello": "world"}`
+
+// runCmd run command with stdin, return exit code & output
+func runCmd(args []string, stdin string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// writeFile write test file into the temporary dir
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// tempDir create temporary dir, it must be removed by the caller
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "httpsig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "No command",
+			args:       nil,
+			wantCode:   2,
+			wantStderr: "Usage: httpsig <command>",
+		},
+		{
+			name:       "Help",
+			args:       []string{"help"},
+			wantCode:   0,
+			wantStdout: "Usage: httpsig <command>",
+		},
+		{
+			name:       "Unknown command",
+			args:       []string{"unknown"},
+			wantCode:   2,
+			wantStderr: "unknown command 'unknown'",
+		},
+		{
+			name:       "Command help",
+			args:       []string{"sign", "-h"},
+			wantCode:   0,
+			wantStderr: "Usage of httpsig sign",
+		},
+		{
+			name:       "Wrong flag",
+			args:       []string{"verify", "-wrong"},
+			wantCode:   2,
+			wantStderr: "flag provided but not defined: -wrong",
+		},
+		{
+			name:       "Several request files",
+			args:       []string{"verify", "a.txt", "b.txt"},
+			wantCode:   2,
+			wantStderr: "only one input file expected, got 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCmd(tt.args, "")
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestReadRequest(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := writeFile(t, dir, "request.txt", testRequest)
+
+	for _, in := range []string{path, "-"} {
+		r, err := readRequest(in, strings.NewReader(testRequest), "http")
+		if err != nil {
+			t.Fatalf("%s: %s", in, err)
+		}
+		if got := r.URL.String(); got != "http://example.com/foo?param=Value&Pet=dog" {
+			t.Errorf("%s: url = %s", in, got)
+		}
+		for i := 0; i < 2; i++ {
+			body, _ := requestBody(r)
+			if body != `{"hello": "world"}` {
+				t.Errorf("%s: body = %q", in, body)
+			}
+		}
+	}
+
+	if _, err := readRequest(filepath.Join(dir, "missing.txt"), nil, "https"); err == nil {
+		t.Error("missing file: error expected")
+	}
+	if _, err := readRequest("-", strings.NewReader("wrong request\r\n\r\n"), "https"); err == nil ||
+		!strings.HasPrefix(err.Error(), "error reading request") {
+		t.Errorf("wrong request: error = %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"httpsignatures"
+)
+
+// Signature formats of the sign command
+const (
+	formatSignature     = "signature"
+	formatAuthorization = "authorization"
+	formatRFC9421       = "rfc9421"
+)
+
+// defaultMessageComponents RFC 9421 components covered if -headers option is not set
+var defaultMessageComponents = []string{"@method", "@authority", "@path"}
+
+func sign(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("sign", stderr)
+	var kf keyFlags
+	kf.register(fs)
+	format := fs.String("format", formatSignature, "signature format: signature, authorization (draft-cavage) or rfc9421")
+	headers := fs.String("headers", "", "space-separated signed headers/components (default: library defaults)")
+	label := fs.String("label", "", "RFC 9421 signature label (default: sig1)")
+	expires := fs.Duration(
+		"expires", 0, "signature expiration period, e.g. 5m (draft-cavage: (expires) is added to -headers)",
+	)
+	curl := fs.Bool("curl", false, "print curl command instead of the raw request")
+	scheme := fs.String("scheme", "https", "request URL scheme")
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := kf.secrets()
+	if err != nil {
+		return err
+	}
+	secret, err := signingSecret(secrets, kf.keyID)
+	if err != nil {
+		return err
+	}
+	r, err := readRequest(path, stdin, *scheme)
+	if err != nil {
+		return err
+	}
+
+	hs := httpsignatures.NewHTTPSignatures(httpsignatures.NewSecretsStorage(secrets))
+	components := strings.Fields(*headers)
+	switch *format {
+	case formatSignature, formatAuthorization:
+		// Library default headers are signed if -headers option is not set
+		if *expires > 0 {
+			if len(components) == 0 {
+				fmt.Fprintln(stderr, "-expires requires -headers for draft-cavage formats")
+				return errUsage
+			}
+			hs.SetDefaultExpiresPeriod(*expires)
+			if !contains(components, "(expires)") {
+				components = append(components, "(expires)")
+			}
+		}
+		if len(components) > 0 {
+			hs.SetDefaultSignatureHeaders(components)
+		}
+		if *format == formatSignature {
+			err = hs.AddSignature(secret, r)
+		} else {
+			err = hs.AddAuthorization(secret, r)
+		}
+	case formatRFC9421:
+		if len(components) == 0 {
+			components = defaultMessageComponents
+		}
+		o := httpsignatures.MessageSignatureOptions{Label: *label, Components: components, Created: time.Now()}
+		if *expires > 0 {
+			o.Expires = o.Created.Add(*expires)
+		}
+		err = hs.SignMessage(secret, r, o)
+	default:
+		fmt.Fprintf(stderr, "unknown format '%s'\n", *format)
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	if *curl {
+		return writeCurl(stdout, r)
+	}
+	return writeRequest(stdout, r)
+}
+
+// signingSecret secret to sign with: selected by keyId or the only one loaded
+func signingSecret(secrets map[string]httpsignatures.Secret, keyID string) (httpsignatures.Secret, error) {
+	if len(keyID) > 0 {
+		s, ok := secrets[keyID]
+		if !ok {
+			return httpsignatures.Secret{}, fmt.Errorf("key '%s' not found", keyID)
+		}
+		return s, nil
+	}
+	if len(secrets) != 1 {
+		return httpsignatures.Secret{}, errors.New("-key-id is required to select the signing key")
+	}
+	for _, s := range secrets {
+		return s, nil
+	}
+	return httpsignatures.Secret{}, nil
+}
+
+// writeRequest write raw request: unlike http.Request.Write no headers are added
+func writeRequest(w io.Writer, r *http.Request) error {
+	body, err := requestBody(r)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(w, "%s %s %s\r\nHost: %s\r\n", r.Method, r.URL.RequestURI(), r.Proto, r.Host); err != nil {
+		return err
+	}
+	if err = r.Header.Write(w); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\r\n"+body)
+	return err
+}
+
+// writeCurl write curl command sending the request, headers are sorted by name
+func writeCurl(w io.Writer, r *http.Request) error {
+	body, err := requestBody(r)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(r.Header))
+	for k := range r.Header {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("curl -X " + shellQuote(r.Method) + " " + shellQuote(r.URL.String()))
+	for _, k := range names {
+		for _, v := range r.Header[k] {
+			b.WriteString(" \\\n  -H " + shellQuote(k+": "+v))
+		}
+	}
+	if len(body) > 0 {
+		b.WriteString(" \\\n  --data-binary " + shellQuote(body))
+	}
+	b.WriteString("\n")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func requestBody(r *http.Request) (string, error) {
+	if r.GetBody == nil {
+		return "", nil
+	}
+	rc, err := r.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	return string(b), err
+}
+
+// shellQuote quote string for POSIX shell, single quote is written as closing quote, escaped quote & opening quote
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	key := writeFile(t, dir, "hmac.key", "test-secret\n")
+	req := writeFile(t, dir, "request.txt", testRequest)
+	keyArgs := []string{"-key", key, "-key-id", "k1", "-alg", "HMAC-SHA256"}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout []string
+		wantStderr string
+	}{
+		{
+			name:     "Signature header",
+			args:     []string{"-headers", "(request-target) (created) date digest"},
+			wantCode: 0,
+			wantStdout: []string{
+				"POST /foo?param=Value&Pet=dog HTTP/1.1\r\nHost: example.com\r\n",
+				"Digest: SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=\r\n",
+				`Signature: keyId="k1",algorithm="hmac-sha256",created=`,
+				`headers="(request-target) (created) date digest"`,
+				"\r\n\r\n{\"hello\": \"world\"}",
+			},
+		},
+		{
+			name:       "Authorization header with expires",
+			args:       []string{"-format", "authorization", "-expires", "5m", "-headers", "(request-target) (created) host"},
+			wantCode:   0,
+			wantStdout: []string{`Authorization: Signature keyId="k1"`, `headers="(request-target) (created) host (expires)"`},
+		},
+		{
+			name:     "Library default headers",
+			args:     []string{},
+			wantCode: 0,
+			wantStdout: []string{
+				`Signature: keyId="k1",algorithm="hmac-sha256",created=`,
+				`headers="(request-target) (created) host"`,
+			},
+		},
+		{
+			name:       "Expires without headers",
+			args:       []string{"-expires", "5m"},
+			wantCode:   2,
+			wantStderr: "-expires requires -headers for draft-cavage formats",
+		},
+		{
+			name:     "RFC 9421",
+			args:     []string{"-format", "rfc9421", "-label", "req", "-headers", "@method @path content-type"},
+			wantCode: 0,
+			wantStdout: []string{
+				`Signature-Input: req=("@method" "@path" "content-type");created=`,
+				`;keyid="k1"`,
+				"Signature: req=:",
+			},
+		},
+		{
+			name:     "Curl",
+			args:     []string{"-curl", "-headers", "(created) date"},
+			wantCode: 0,
+			wantStdout: []string{
+				"curl -X 'POST' 'https://example.com/foo?param=Value&Pet=dog' \\\n",
+				"  -H 'Date: Tue, 20 Apr 2021 02:07:55 GMT' \\\n",
+				"  -H 'Signature: keyId=\"k1\"",
+				"  --data-binary '{\"hello\": \"world\"}'\n",
+			},
+		},
+		{
+			name:       "Unknown format",
+			args:       []string{"-format", "wrong"},
+			wantCode:   2,
+			wantStderr: "unknown format 'wrong'",
+		},
+		{
+			name:       "Unsupported algorithm",
+			args:       []string{"-alg", "ED25519"},
+			wantCode:   1,
+			wantStderr: "httpsig sign: algorithm 'ED25519' not supported",
+		},
+		{
+			name:       "Header not found",
+			args:       []string{"-headers", "x-missing"},
+			wantCode:   1,
+			wantStderr: "header 'x-missing', required in signature, not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(append([]string{"sign"}, keyArgs...), tt.args...)
+			code, stdout, stderr := runCmd(append(args, req), "")
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d, stderr: %s", code, tt.wantCode, stderr)
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("stdout = %q, want %q", stdout, want)
+				}
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		``:          `''`,
+		`a b`:       `'a b'`,
+		`it's "ok"`: `'it'\''s "ok"'`,
+	}
+	for s, want := range tests {
+		if got := shellQuote(s); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
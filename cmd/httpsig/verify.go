@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"httpsignatures"
+)
+
+// errVerificationFailed some of the checks failed, details are already printed
+var errVerificationFailed = errors.New("verification failed")
+
+// report prints outcome of verification checks, failed checks are counted
+type report struct {
+	w      io.Writer
+	failed int
+}
+
+func (rep *report) check(name string, err error) {
+	if err != nil {
+		rep.failed++
+		fmt.Fprintf(rep.w, "%s: FAILED (%s)\n", name, err)
+		return
+	}
+	fmt.Fprintf(rep.w, "%s: ok\n", name)
+}
+
+func (rep *report) value(name string, v interface{}) {
+	fmt.Fprintf(rep.w, "%s: %v\n", name, v)
+}
+
+// signingString print signing string indented, one line per component
+func (rep *report) signingString(name string, s string) {
+	fmt.Fprintf(rep.w, "%s:\n", name)
+	for _, line := range strings.Split(s, "\n") {
+		fmt.Fprintf(rep.w, "  %s\n", line)
+	}
+}
+
+func verify(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("verify", stderr)
+	var kf keyFlags
+	kf.register(fs)
+	strict := fs.Bool("strict", false, "reject draft-cavage headers not conforming to the draft ABNF")
+	scheme := fs.String("scheme", "https", "request URL scheme")
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := kf.secrets()
+	if err != nil {
+		return err
+	}
+	r, err := readRequest(path, stdin, *scheme)
+	if err != nil {
+		return err
+	}
+
+	hs := httpsignatures.NewHTTPSignatures(httpsignatures.NewSecretsStorage(secrets))
+	hs.SetStrictParsing(*strict)
+	rep := &report{w: stdout}
+	format := hs.DetectFormat(r)
+	rep.value("format", format)
+	switch format {
+	case httpsignatures.CavageSignatureFormat, httpsignatures.CavageAuthorizationFormat:
+		verifyCavage(hs, r, format, *strict, rep)
+	case httpsignatures.MessageSignatureFormat:
+		verifyMessage(hs, r, rep)
+	default:
+		rep.check("signature", errors.New("signature not found"))
+	}
+
+	if rep.failed > 0 {
+		return errVerificationFailed
+	}
+	return nil
+}
+
+func verifyCavage(
+	hs *httpsignatures.HTTPSignatures,
+	r *http.Request,
+	format httpsignatures.SignatureFormat,
+	strict bool,
+	rep *report,
+) {
+	p := httpsignatures.NewParser()
+	if strict {
+		p = httpsignatures.NewStrictParser()
+	}
+	var ph httpsignatures.ParsedHeader
+	var pErr *httpsignatures.ParserError
+	if format == httpsignatures.CavageSignatureFormat {
+		ph, pErr = p.ParseSignatureHeader(r.Header.Get("Signature"))
+	} else {
+		ph, pErr = p.ParseAuthorizationHeader(r.Header.Get("Authorization"))
+	}
+	if pErr != nil {
+		rep.check("parse", pErr)
+		return
+	}
+	rep.check("parse", nil)
+	rep.value("keyId", ph.KeyID())
+	rep.value("algorithm", ph.Algorithm())
+	rep.value("headers", strings.Join(ph.Headers(), " "))
+
+	res, verifyErr := hs.Verify(r)
+	checkKey(verifyErr, rep)
+	checkDigest(r, rep)
+	checkExpires(res, verifyErr, rep)
+
+	sigStr, err := hs.SignatureString(r, ph)
+	if err != nil {
+		rep.check("signing string", err)
+	} else {
+		rep.signingString("signing string", sigStr)
+	}
+	checkSignature(verifyErr, rep)
+}
+
+func verifyMessage(
+	hs *httpsignatures.HTTPSignatures,
+	r *http.Request,
+	rep *report,
+) {
+	labels, err := hs.MessageSignatureLabels(r)
+	rep.check("parse", err)
+	if err != nil {
+		return
+	}
+	checkDigest(r, rep)
+
+	for _, label := range labels {
+		rep.value("label", label)
+		base, err := hs.MessageSignatureBase(r, label)
+		if err != nil {
+			rep.check("signature base", err)
+		} else {
+			rep.signingString("signature base", base)
+		}
+		results, err := hs.VerifyMessageSignatures(r, httpsignatures.MessageSignatureSelector{Labels: []string{label}})
+		if len(results) == 0 {
+			rep.check("signature", err)
+			continue
+		}
+		res := results[0]
+		checkKey(res.Err, rep)
+		checkExpires(res.VerificationResult, res.Err, rep)
+		checkSignature(res.Err, rep)
+	}
+}
+
+// checkKey report key lookup failed with the verification error. Draft-cavage signature expiration is checked
+// before the key lookup, so the key isn't reported for expired signatures
+func checkKey(err error, rep *report) {
+	if errors.Is(err, httpsignatures.ErrExpired) {
+		return
+	}
+	rep.check("key", errorOfKind(err, httpsignatures.ErrKeyNotFound))
+}
+
+// checkDigest verify Digest header if it's sent
+func checkDigest(r *http.Request, rep *report) {
+	if len(r.Header.Get("Digest")) == 0 {
+		return
+	}
+	rep.check("digest", httpsignatures.NewDigest().Verify(r))
+}
+
+// checkExpires report expiration of the signature with expires param
+func checkExpires(res httpsignatures.VerificationResult, err error, rep *report) {
+	if !res.Expires.IsZero() {
+		rep.check("expires", errorOfKind(err, httpsignatures.ErrExpired))
+	}
+}
+
+// checkSignature report verification error unless it's already reported by the key or expires check
+func checkSignature(err error, rep *report) {
+	if errors.Is(err, httpsignatures.ErrKeyNotFound) || errors.Is(err, httpsignatures.ErrExpired) {
+		return
+	}
+	rep.check("signature", err)
+}
+
+// errorOfKind verification error if it's of the kind, e.g. ErrKeyNotFound
+func errorOfKind(err error, kind error) error {
+	if errors.Is(err, kind) {
+		return err
+	}
+	return nil
+}
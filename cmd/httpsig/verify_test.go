@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	pub, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+	manifest, _ := json.Marshal([]manifestSecret{
+		{KeyID: "rsa", Algorithm: "RSA-SHA256", PublicKey: publicKey},
+		{KeyID: "hmac", Algorithm: "HMAC-SHA256", PrivateKey: "test-secret"},
+	})
+	keysFile := writeFile(t, dir, "keys.json", string(manifest))
+	privateKeyFile := writeFile(t, dir, "rsa.pem", privateKey)
+	hmacKeyFile := writeFile(t, dir, "hmac.key", "test-secret")
+	req := writeFile(t, dir, "request.txt", testRequest)
+
+	signed := func(args ...string) string {
+		code, stdout, stderr := runCmd(append(append([]string{"sign"}, args...), req), "")
+		if code != 0 {
+			t.Fatalf("sign %v: %s", args, stderr)
+		}
+		return stdout
+	}
+
+	tests := []struct {
+		name       string
+		request    string
+		args       []string
+		wantCode   int
+		wantStdout []string
+	}{
+		{
+			name:     "RSA signature",
+			request:  signed("-key", privateKeyFile, "-key-id", "rsa", "-alg", "RSA-SHA256", "-headers", "(created) date digest"),
+			wantCode: 0,
+			wantStdout: []string{
+				"format: cavage-signature\nparse: ok\nkeyId: rsa\nalgorithm: rsa-sha256\nheaders: (created) date digest\n",
+				"key: ok\ndigest: ok\nsigning string:\n  (created): ",
+				"\n  date: Tue, 20 Apr 2021 02:07:55 GMT\n  digest: SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=\n",
+				"signature: ok\n",
+			},
+		},
+		{
+			name:       "HMAC authorization",
+			request:    signed("-key", hmacKeyFile, "-key-id", "hmac", "-alg", "HMAC-SHA256", "-format", "authorization"),
+			wantCode:   0,
+			wantStdout: []string{"format: cavage-authorization\n", "signature: ok\n"},
+		},
+		{
+			name: "Lowercase authorization scheme",
+			request: strings.Replace(
+				signed("-key", hmacKeyFile, "-key-id", "hmac", "-alg", "HMAC-SHA256", "-format", "authorization"),
+				"Authorization: Signature ", "Authorization: signature ", 1,
+			),
+			wantCode:   0,
+			wantStdout: []string{"format: cavage-authorization\nparse: ok\nkeyId: hmac\n", "signature: ok\n"},
+		},
+		{
+			name: "RFC 9421",
+			request: signed(
+				"-key", hmacKeyFile, "-key-id", "hmac", "-alg", "HMAC-SHA256", "-format", "rfc9421", "-expires", "1h",
+			),
+			wantCode: 0,
+			wantStdout: []string{
+				"format: rfc9421\nparse: ok\nlabel: sig1\nsignature base:\n",
+				"  \"@method\": POST\n  \"@authority\": example.com\n  \"@path\": /foo\n",
+				"key: ok\nexpires: ok\nsignature: ok\n",
+			},
+		},
+		{
+			name: "Tampered request",
+			request: strings.Replace(
+				signed("-key", hmacKeyFile, "-key-id", "hmac", "-alg", "HMAC-SHA256", "-headers", "(request-target) digest"),
+				`"world"`, `"World"`, 1,
+			),
+			wantCode: 1,
+			wantStdout: []string{
+				"digest: FAILED (DigestError: wrong digest: CryptoError: wrong hash)\n",
+				"signature: FAILED (DigestError: wrong digest: CryptoError: wrong hash)\n",
+			},
+		},
+		{
+			name:     "Unknown key",
+			request:  signed("-key", hmacKeyFile, "-key-id", "unknown", "-alg", "HMAC-SHA256"),
+			wantCode: 1,
+			wantStdout: []string{
+				"key: FAILED (keyID 'unknown' not found: SecretError: secret not found)\nsigning string:\n",
+				"  host: example.com\n",
+			},
+		},
+		{
+			name: "Expired signature",
+			request: strings.Replace(testRequest, "\r\n\r\n", "\r\nSignature: keyId=\"hmac\",algorithm=\"hmac-sha256\","+
+				"created=1,expires=2,headers=\"(created) (expires)\",signature=\"c2ln\"\r\n\r\n", 1),
+			wantCode: 1,
+			wantStdout: []string{
+				"headers: (created) (expires)\nexpires: FAILED (signature expired)\nsigning string:\n",
+				"  (expires): 2\n",
+			},
+		},
+		{
+			name: "Expired RFC 9421 signature",
+			request: strings.Replace(testRequest, "\r\n\r\n", "\r\n"+
+				"Signature-Input: sig1=(\"@method\");created=1;expires=2;keyid=\"hmac\"\r\nSignature: sig1=:c2ln:\r\n\r\n", 1),
+			wantCode:   1,
+			wantStdout: []string{"keyid=\"hmac\"\nexpires: FAILED (signature 'sig1' expired)\n"},
+		},
+		{
+			name:       "Signature not found",
+			request:    testRequest,
+			wantCode:   1,
+			wantStdout: []string{"format: unknown\nsignature: FAILED (signature not found)\n"},
+		},
+		{
+			name:       "Strict parsing",
+			request:    strings.Replace(testRequest, "\r\n\r\n", "\r\nSignature: keyId=\"hmac\",created=1.5\r\n\r\n", 1),
+			args:       []string{"-strict"},
+			wantCode:   1,
+			wantStdout: []string{"parse: FAILED (ParserError: wrong 'created' param value: decimal value \"1.5\" not allowed)\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(append([]string{"verify", "-keys", keysFile}, tt.args...), "-")
+			code, stdout, stderr := runCmd(args, tt.request)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d, stderr: %s", code, tt.wantCode, stderr)
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("stdout = %q, want %q", stdout, want)
+				}
+			}
+		})
+	}
+}
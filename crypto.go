@@ -16,6 +16,11 @@ type SignatureHashAlgorithm interface {
 	Verify(secret Secret, data []byte, signature []byte) error
 }
 
+// KeySizer optional interface for SignatureHashAlgorithm to report size of the key in bits (used by Policy)
+type KeySizer interface {
+	KeySize(secret Secret) (int, error)
+}
+
 // DigestHashAlgorithm interface to create/verify digest HMAC hash
 type DigestHashAlgorithm interface {
 	Algorithm() string
@@ -40,6 +45,11 @@ func (e *CryptoError) Error() string {
 	return fmt.Sprintf("CryptoError: %s", e.Message)
 }
 
+// Unwrap wrapped error
+func (e *CryptoError) Unwrap() error {
+	return e.Err
+}
+
 func digestHashAlgorithmVerify(newHash func() hash.Hash, data []byte, digest []byte) error {
 	expected, err := digestHashAlgorithmCreate(newHash, data)
 	if err != nil {
@@ -1,8 +1,13 @@
 package httpsignatures
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/pem"
 	"testing"
 )
 
@@ -571,3 +576,38 @@ yEh6Szz2in47Tv5n52m9dLYyPCbqZkOB5nTSqtscpkQD/HpykCggvx09iQ==
 		})
 	}
 }
+
+func TestRsaSha256KeyEncodings(t *testing.T) {
+	block, _ := pem.Decode([]byte(rsaPrivateKey))
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+	privateKeys := map[string]string{
+		"PKCS#1": rsaPrivateKey,
+		"PKCS#8": string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+	}
+	publicKeys := map[string]string{
+		"PKIX":   rsaPublicKey,
+		"PKCS#1": string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})),
+	}
+	data := []byte("(request-target): post /foo?param=value&pet=dog")
+	for privateName, privateKey := range privateKeys {
+		for publicName, publicKey := range publicKeys {
+			name := privateName + " private key, " + publicName + " public key"
+			secret := Secret{PrivateKey: privateKey, PublicKey: publicKey}
+			sig, err := RsaSha256{}.Create(secret, data)
+			if err == nil {
+				err = RsaSha256{}.Verify(secret, data, sig)
+			}
+			assert(t, err == nil, err, cryptoErrType, name, true, "")
+		}
+	}
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecPKCS8, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+	ecPrivateKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecPKCS8}))
+	_, err = RsaSha256{}.Create(Secret{PrivateKey: ecPrivateKey}, data)
+	assert(t, false, err, cryptoErrType, "PKCS#8 EC private key", false, "CryptoError: unknown type of private key")
+}
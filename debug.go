@@ -0,0 +1,115 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"httpsignatures/sfv"
+)
+
+// redactedValue replacement of sensitive header values in the signature string
+const redactedValue = "[redacted]"
+
+// defaultRedactedHeaders headers with credentials, redacted in the signature string of debug errors
+var defaultRedactedHeaders = []string{"authorization", "proxy-authorization", "cookie", "set-cookie"}
+
+// SetDebug include signature string (RFC 9421 signature base) built by the verifier in `wrong signature` errors
+// & VerificationResult, so it could be compared with the string signed by the sender. Values of sensitive headers
+// are redacted
+func (hs *HTTPSignatures) SetDebug(debug bool) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.debug = debug
+}
+
+// SetDebugRedactedHeaders set list of headers redacted in the signature string of debug mode
+// (default: `authorization proxy-authorization cookie set-cookie`)
+func (hs *HTTPSignatures) SetDebugRedactedHeaders(headers []string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.redactedHeaders = headers
+}
+
+// SignatureString signature string of the request built for the parsed Signature|Authorization header
+// exactly as it's signed & verified
+func (hs *HTTPSignatures) SignatureString(r *http.Request, ph ParsedHeader) (string, error) {
+	return hs.signatureString(ph, requestMessage(r))
+}
+
+// ResponseSignatureString signature string of the response built for the parsed Signature header
+func (hs *HTTPSignatures) ResponseSignatureString(resp *http.Response, ph ParsedHeader) (string, error) {
+	return hs.signatureString(ph, responseMessage(resp))
+}
+
+// MessageSignatureBase RFC 9421 signature base of the request built for the labelled signature
+// exactly as it's signed & verified
+func (hs *HTTPSignatures) MessageSignatureBase(r *http.Request, label string) (string, error) {
+	inputs, err := hs.parseDictionaryHeader(r.Header, signatureInputHeader)
+	if err != nil {
+		return "", err
+	}
+	member, ok := inputs.Get(label)
+	if !ok {
+		return "", &Error{fmt.Sprintf("signature input '%s' not found", label), withKind(nil, ErrMissingHeader)}
+	}
+	input, ok := member.(sfv.InnerList)
+	if !ok {
+		return "", &Error{
+			fmt.Sprintf("signature input '%s' must be an inner list", label),
+			withKind(nil, ErrMalformedHeader),
+		}
+	}
+	b, err := hs.buildSignatureBase(input, requestMessage(r))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (hs *HTTPSignatures) signatureString(ph ParsedHeader, m message) (string, error) {
+	b, err := hs.buildSignatureString(ph, m)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// debugSignatureString signature string or signature base with redacted values of sensitive headers,
+// empty if debug is disabled
+func (hs *HTTPSignatures) debugSignatureString(sigStr []byte) string {
+	hs.mu.RLock()
+	debug := hs.debug
+	redacted := hs.redactedHeaders
+	hs.mu.RUnlock()
+	if !debug {
+		return ""
+	}
+
+	lines := strings.Split(string(sigStr), "\n")
+	for i, l := range lines {
+		name := l
+		if j := strings.Index(l, ": "); j != -1 {
+			name = l[:j]
+		}
+		for _, h := range redacted {
+			if strings.EqualFold(headerName(name), h) {
+				lines[i] = name + ": " + redactedValue
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headerName name of the header in the signature string line: RFC 9421 component identifier is quoted
+// & might have parameters, e.g. `"cookie";sf`
+func headerName(name string) string {
+	if len(name) == 0 || name[0] != '"' {
+		return name
+	}
+	if i := strings.IndexByte(name[1:], '"'); i != -1 {
+		return name[1 : i+1]
+	}
+	return name
+}
@@ -0,0 +1,211 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSignatureString(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		want        string
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:        "Signature string",
+			header:      `keyId="test-shared-secret",created=1618884473,headers="(request-target) (created) date",signature="c2ln"`,
+			want:        "(request-target): post /foo?param=Value&Pet=dog\n(created): 1618884473\ndate: Tue, 20 Apr 2021 02:07:55 GMT",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Default headers",
+			header:      `keyId="test-shared-secret",created=1618884473.5,signature="c2ln"`,
+			want:        "(created): 1618884473.5",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Header not found",
+			header:      `keyId="test-shared-secret",headers="date x-missing",signature="c2ln"`,
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "header 'x-missing', required in signature, not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			ph, pErr := NewParser().ParseSignatureHeader(tt.header)
+			if pErr != nil {
+				t.Fatalf("parse error: %s", pErr)
+			}
+			got, err := hs.SignatureString(getMessageSignatureRequestFunc(), ph)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestResponseSignatureString(t *testing.T) {
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	ph, _ := NewParser().ParseSignatureHeader(`keyId="test-shared-secret",headers="(status) content-type"`)
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}}
+	got, err := hs.ResponseSignatureString(resp, ph)
+	assert(t, got, err, httpsignaturesErrType, "Response signature string", "(status): 200\ncontent-type: application/json", "")
+}
+
+func TestDebug(t *testing.T) {
+	const header = `keyId="test-shared-secret",algorithm="hmac-sha256",created=1618884473,` +
+		`headers="(created) date authorization cookie",signature="c2ln"`
+	tests := []struct {
+		name            string
+		debug           bool
+		redactedHeaders []string
+		want            string
+		wantErrType     string
+		wantErrMsg      string
+	}{
+		{
+			name:        "Debug disabled",
+			debug:       false,
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong signature: CryptoError: wrong signature",
+		},
+		{
+			name:        "Debug enabled",
+			debug:       true,
+			want:        "(created): 1618884473\ndate: Tue, 20 Apr 2021 02:07:55 GMT\nauthorization: [redacted]\ncookie: [redacted]",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg: `wrong signature, signature string "(created): 1618884473\ndate: Tue, 20 Apr 2021 02:07:55 GMT\n` +
+				`authorization: [redacted]\ncookie: [redacted]": CryptoError: wrong signature`,
+		},
+		{
+			name:            "Custom redacted headers",
+			debug:           true,
+			redactedHeaders: []string{"Date"},
+			want:            "(created): 1618884473\ndate: [redacted]\nauthorization: Bearer token\ncookie: session=1",
+			wantErrType:     httpsignaturesErrType,
+			wantErrMsg: `wrong signature, signature string "(created): 1618884473\ndate: [redacted]\n` +
+				`authorization: Bearer token\ncookie: session=1": CryptoError: wrong signature`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			hs.SetDebug(tt.debug)
+			if tt.redactedHeaders != nil {
+				hs.SetDebugRedactedHeaders(tt.redactedHeaders)
+			}
+			r := getMessageSignatureRequestFunc()
+			r.Header.Set("Authorization", "Bearer token")
+			r.Header.Set("Cookie", "session=1")
+			r.Header.Set("Signature", header)
+			res, err := hs.Verify(r)
+			assert(t, res.SignatureString, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestDebugMessageSignature(t *testing.T) {
+	const input = `sig1=("date" "authorization" "cookie");created=1618884473;keyid="test-shared-secret"`
+	const params = `"@signature-params": ("date" "authorization" "cookie");created=1618884473;keyid="test-shared-secret"`
+	tests := []struct {
+		name            string
+		debug           bool
+		redactedHeaders []string
+		want            string
+		wantErrType     string
+		wantErrMsg      string
+	}{
+		{
+			name:        "Debug disabled",
+			debug:       false,
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong signature: CryptoError: wrong signature",
+		},
+		{
+			name:  "Debug enabled",
+			debug: true,
+			want: "\"date\": Tue, 20 Apr 2021 02:07:55 GMT\n\"authorization\": [redacted]\n" +
+				"\"cookie\": [redacted]\n" + params,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg: `wrong signature, signature base "\"date\": Tue, 20 Apr 2021 02:07:55 GMT\n` +
+				`\"authorization\": [redacted]\n\"cookie\": [redacted]\n` +
+				`\"@signature-params\": (\"date\" \"authorization\" \"cookie\");created=1618884473;` +
+				`keyid=\"test-shared-secret\"": CryptoError: wrong signature`,
+		},
+		{
+			name:            "Custom redacted headers",
+			debug:           true,
+			redactedHeaders: []string{"Date"},
+			want: "\"date\": [redacted]\n\"authorization\": Bearer token\n" +
+				"\"cookie\": session=1\n" + params,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg: `wrong signature, signature base "\"date\": [redacted]\n` +
+				`\"authorization\": Bearer token\n\"cookie\": session=1\n` +
+				`\"@signature-params\": (\"date\" \"authorization\" \"cookie\");created=1618884473;` +
+				`keyid=\"test-shared-secret\"": CryptoError: wrong signature`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			hs.SetDebug(tt.debug)
+			if tt.redactedHeaders != nil {
+				hs.SetDebugRedactedHeaders(tt.redactedHeaders)
+			}
+			r := getMessageSignatureRequestFunc()
+			r.Header.Set("Authorization", "Bearer token")
+			r.Header.Set("Cookie", "session=1")
+			r.Header.Set("Signature-Input", input)
+			r.Header.Set("Signature", "sig1=:c2ln:")
+			res, err := hs.Verify(r)
+			assert(t, res.SignatureString, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestMessageSignatureBase(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		label       string
+		want        string
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:  "Signature base",
+			input: `sig1=("date" "content-type");created=1618884473;keyid="test-shared-secret"`,
+			label: "sig1",
+			want: "\"date\": Tue, 20 Apr 2021 02:07:55 GMT\n\"content-type\": application/json\n" +
+				"\"@signature-params\": (\"date\" \"content-type\");created=1618884473;keyid=\"test-shared-secret\"",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Label not found",
+			input:       `sig1=("date");keyid="test-shared-secret"`,
+			label:       "sig2",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "signature input 'sig2' not found",
+		},
+		{
+			name:        "Not an inner list",
+			input:       `sig1="date"`,
+			label:       "sig1",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "signature input 'sig1' must be an inner list",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			r := getMessageSignatureRequestFunc()
+			r.Header.Set("Signature-Input", tt.input)
+			got, err := hs.MessageSignatureBase(r, tt.label)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 )
 
 // DigestError errors during digest verification
@@ -20,16 +22,23 @@ func (e *DigestError) Error() string {
 	if e == nil {
 		return ""
 	}
-	if e.Err != nil {
-		return fmt.Sprintf("DigestError: %s: %s", e.Message, e.Err.Error())
+	if msg := errorMessage(e.Err); len(msg) > 0 {
+		return fmt.Sprintf("DigestError: %s: %s", e.Message, msg)
 	}
 	return fmt.Sprintf("DigestError: %s", e.Message)
 }
 
+// Unwrap wrapped error
+func (e *DigestError) Unwrap() error {
+	return e.Err
+}
+
 // Digest digest internal struct
+// Digest is safe for concurrent use by multiple goroutines
 type Digest struct {
-	parsedDigestHeader ParsedDigestHeader
-	alg                map[string]DigestHashAlgorithm
+	mu     sync.RWMutex
+	alg    map[string]DigestHashAlgorithm
+	policy Policy
 }
 
 // NewDigest create new digest
@@ -45,68 +54,131 @@ func NewDigest() *Digest {
 
 // SetDigestHashAlgorithm set digest options (add new digest hash algorithm)
 func (d *Digest) SetDigestHashAlgorithm(a DigestHashAlgorithm) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.alg[strings.ToUpper(a.Algorithm())] = a
 }
 
+// SetPolicy set digest policy (allowed digest hash algorithms). Policy is copied: changes of its lists
+// made by the caller later don't affect verification
+func (d *Digest) SetPolicy(p Policy) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.policy = p.clone()
+}
+
+// Create create digest header value (algorithm=base64 hash) of the request body
+func (d *Digest) Create(alg string, r *http.Request) (string, error) {
+	b, dErr := d.readBody(&r.Body)
+	if dErr != nil {
+		return "", dErr
+	}
+	return d.create(alg, b)
+}
+
 // Verify verify digest header (compare with real request body hash)
 func (d *Digest) Verify(r *http.Request) error {
+	return d.verify(r.Header, &r.Body)
+}
+
+// VerifyResponse verify digest header of the response (compare with real response body hash)
+func (d *Digest) VerifyResponse(resp *http.Response) error {
+	return d.verify(resp.Header, &resp.Body)
+}
+
+func (d *Digest) create(alg string, b []byte) (string, error) {
+	d.mu.RLock()
+	h, ok := d.alg[strings.ToUpper(alg)]
+	d.mu.RUnlock()
+	if !ok {
+		return "", &DigestError{
+			fmt.Sprintf("unsupported digest hash algorithm '%s'", alg),
+			withKind(nil, ErrWrongAlgorithm),
+		}
+	}
+
+	digest, err := h.Create(b)
+	if err != nil {
+		return "", &DigestError{"error creating digest", err}
+	}
+
+	return fmt.Sprintf("%s=%s", h.Algorithm(), base64.StdEncoding.EncodeToString(digest)), nil
+}
+
+func (d *Digest) verify(header http.Header, body *io.ReadCloser) error {
 	var err error
-	var pErr *ParserError
 	var dErr *DigestError
 
-	header := r.Header.Get("digest")
-	p := NewParser()
-	d.parsedDigestHeader, pErr = p.ParseDigestHeader(header)
+	v := header.Get(digestHeader)
+	if len(v) == 0 {
+		return &DigestError{"digest header not found", withKind(nil, ErrMissingHeader)}
+	}
+	p := acquireParser(false)
+	defer releaseParser(p)
+	parsedDigestHeader, pErr := p.ParseDigestHeader(v)
 	if pErr != nil {
 		return pErr
 	}
 
-	h, ok := d.alg[strings.ToUpper(d.parsedDigestHeader.algo)]
+	d.mu.RLock()
+	h, ok := d.alg[strings.ToUpper(parsedDigestHeader.algo)]
+	policy := d.policy
+	d.mu.RUnlock()
 	if !ok {
 		return &DigestError{
-			fmt.Sprintf("unsupported digest hash algorithm '%s'", d.parsedDigestHeader.algo),
-			nil,
+			fmt.Sprintf("unsupported digest hash algorithm '%s'", parsedDigestHeader.algo),
+			withKind(nil, ErrWrongAlgorithm),
+		}
+	}
+
+	if !policy.isDigestAlgorithmAllowed(parsedDigestHeader.algo) {
+		return &DigestError{
+			fmt.Sprintf("digest hash algorithm '%s' not allowed by policy", parsedDigestHeader.algo),
+			withKind(nil, ErrPolicyViolation),
 		}
 	}
 
-	b, dErr := d.readBody(r)
+	b, dErr := d.readBody(body)
 	if dErr != nil {
 		return dErr
 	}
 
-	digest, err := base64.StdEncoding.DecodeString(d.parsedDigestHeader.digest)
+	digest, err := base64.StdEncoding.DecodeString(parsedDigestHeader.digest)
 	if err != nil {
 		return &DigestError{
 			"error decode digest from base64",
-			err,
+			withKind(err, ErrMalformedHeader),
 		}
 	}
 	err = h.Verify(b, digest)
 	if err != nil {
 		return &DigestError{
 			"wrong digest",
-			err,
+			withKind(err, ErrDigestMismatch),
 		}
 	}
 
 	return nil
 }
 
-func (d *Digest) readBody(r *http.Request) ([]byte, *DigestError) {
-	if r.ContentLength == 0 {
-		return []byte{}, &DigestError{"empty body", nil}
+// readBody read whole message body regardless of ContentLength: chunked & server requests could report
+// unknown (-1) or zero length. Message without body is an empty payload, its digest is verified as well.
+// Body is replaced with a new reader, so it could be read again.
+func (d *Digest) readBody(body *io.ReadCloser) ([]byte, *DigestError) {
+	if *body == nil || *body == http.NoBody {
+		return []byte{}, nil
 	}
 
-	body, err := ioutil.ReadAll(r.Body)
+	b, err := ioutil.ReadAll(*body)
 	if err != nil {
 		return []byte{}, &DigestError{"error reading body", err}
 	}
 
-	err = r.Body.Close()
+	err = (*body).Close()
 	if err != nil {
 		return []byte{}, &DigestError{"error closing body", err}
 	}
-	r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	*body = ioutil.NopCloser(bytes.NewBuffer(b))
 
-	return body, nil
+	return b, nil
 }
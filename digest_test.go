@@ -1,8 +1,10 @@
 package httpsignatures
 
 import (
+	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -105,11 +107,55 @@ func TestVerifyDigest(t *testing.T) {
 		{
 			name: "Empty body",
 			args: args{
-				r: getDigestRequestFunc("", "MD5=xxx"),
+				r: getDigestRequestFunc("", "MD5=1B2M2Y8AsgTpgAmY7PhCfg=="),
+			},
+			want:        true,
+			wantErrType: digestErrType,
+		},
+		{
+			name: "Empty body wrong digest",
+			args: args{
+				r: getDigestRequestFunc("", "MD5=Sd/dVLAcvNLSq16eXua5uQ=="),
 			},
 			want:        false,
 			wantErrType: digestErrType,
-			wantErrMsg:  "DigestError: empty body",
+			wantErrMsg:  "DigestError: wrong digest: CryptoError: wrong hash",
+		},
+		{
+			name: "Nil body",
+			args: args{
+				r: (func() *http.Request {
+					r := getDigestRequestFunc("", "SHA-256=47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+					r.Body = nil
+					return r
+				})(),
+			},
+			want:        true,
+			wantErrType: digestErrType,
+		},
+		{
+			name: "Unknown content length",
+			args: args{
+				r: (func() *http.Request {
+					r := getDigestRequestFunc(digestBodyExample, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+					r.ContentLength = -1
+					return r
+				})(),
+			},
+			want:        true,
+			wantErrType: digestErrType,
+		},
+		{
+			name: "Zero content length with body",
+			args: args{
+				r: (func() *http.Request {
+					r := getDigestRequestFunc(digestBodyExample, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+					r.ContentLength = 0
+					return r
+				})(),
+			},
+			want:        true,
+			wantErrType: digestErrType,
 		},
 	}
 	for _, tt := range tests {
@@ -142,3 +188,39 @@ func TestDigestSetDigestHashAlgorithm(t *testing.T) {
 		})
 	}
 }
+
+func TestDigestVerifyConcurrent(t *testing.T) {
+	d := NewDigest()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r := getDigestRequestFunc(digestBodyExample, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+			if err := d.Verify(r); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			d.SetDigestHashAlgorithm(testAlg{})
+			d.SetPolicy(Policy{})
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDigestVerifyRestoresBody(t *testing.T) {
+	r := getDigestRequestFunc(digestBodyExample, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+	r.ContentLength = -1
+	if err := NewDigest().Verify(r); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(b) != digestBodyExample {
+		t.Errorf("got body = %s, want = %s", string(b), digestBodyExample)
+	}
+}
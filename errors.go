@@ -0,0 +1,57 @@
+package httpsignatures
+
+import "errors"
+
+// Kinds of verification errors to check with errors.Is, e.g. to map them to HTTP statuses
+var (
+	// ErrMissingHeader signature header or covered header/component not found
+	ErrMissingHeader = errors.New("missing header")
+	// ErrMalformedHeader signature header or its params could not be parsed
+	ErrMalformedHeader = errors.New("malformed header")
+	// ErrKeyNotFound secret for keyID not found
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrWrongAlgorithm algorithm not supported or doesn't match the key
+	ErrWrongAlgorithm = errors.New("wrong algorithm")
+	// ErrPolicyViolation algorithm, key size or covered components not allowed by the verification policy
+	ErrPolicyViolation = errors.New("policy violation")
+	// ErrExpired signature expired
+	ErrExpired = errors.New("signature expired")
+	// ErrDigestMismatch digest header doesn't match the body
+	ErrDigestMismatch = errors.New("digest mismatch")
+	// ErrInvalidSignature signature doesn't match the message
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// kindError attaches kind of the error to the wrapped error without changing the error message
+type kindError struct {
+	kind error
+	err  error
+}
+
+// withKind wrap error (might be nil) with the kind to check with errors.Is
+func withKind(err, kind error) error {
+	return &kindError{kind, err}
+}
+
+// Error message of the wrapped error
+func (e *kindError) Error() string {
+	return errorMessage(e.err)
+}
+
+// Unwrap wrapped error
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+// Is check kind of the error
+func (e *kindError) Is(target error) bool {
+	return e.kind == target
+}
+
+// errorMessage message of the error, empty for nil error
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,169 @@
+package httpsignatures
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorKinds(t *testing.T) {
+	hmac, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	tests := []struct {
+		name string
+		sign func(hs *HTTPSignatures, r *http.Request)
+		want error
+	}{
+		{
+			name: "No signature",
+			sign: func(hs *HTTPSignatures, r *http.Request) {},
+			want: ErrMissingHeader,
+		},
+		{
+			name: "Covered header not found",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				_ = hs.SignMessage(hmac, r, MessageSignatureOptions{Components: []string{"date"}})
+				r.Header.Del("Date")
+			},
+			want: ErrMissingHeader,
+		},
+		{
+			name: "Digest header not found",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				hs.SetDefaultSignatureHeaders([]string{"digest"})
+				_ = hs.AddSignature(hmac, r)
+				r.Header.Del("Digest")
+			},
+			want: ErrMissingHeader,
+		},
+		{
+			name: "Malformed cavage header",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				r.Header.Set("Signature", `keyId=`)
+			},
+			want: ErrMalformedHeader,
+		},
+		{
+			name: "Malformed RFC 9421 header",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				r.Header.Set("Signature-Input", `sig1=(`)
+			},
+			want: ErrMalformedHeader,
+		},
+		{
+			name: "Unknown key",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				r.Header.Set("Signature", `keyId="unknown",algorithm="hmac-sha256",signature="c2ln"`)
+			},
+			want: ErrKeyNotFound,
+		},
+		{
+			name: "Wrong algorithm",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				r.Header.Set("Signature", `keyId="test-shared-secret",algorithm="rsa-sha256",signature="c2ln"`)
+			},
+			want: ErrWrongAlgorithm,
+		},
+		{
+			name: "Policy violation",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				_ = hs.SignMessage(hmac, r, MessageSignatureOptions{Components: []string{"date"}})
+				hs.SetPolicy(Policy{RequiredComponents: []string{"@method"}})
+			},
+			want: ErrPolicyViolation,
+		},
+		{
+			name: "Expired signature",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				_ = hs.SignMessage(hmac, r, MessageSignatureOptions{Expires: time.Now().Add(-time.Second)})
+			},
+			want: ErrExpired,
+		},
+		{
+			name: "Expired cavage signature",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				hs.SetDefaultSignatureHeaders([]string{"(created)", "(expires)", "date"})
+				hs.SetDefaultExpiresPeriod(-time.Second)
+				_ = hs.AddSignature(hmac, r)
+			},
+			want: ErrExpired,
+		},
+		{
+			name: "Digest mismatch",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				hs.SetDefaultSignatureHeaders([]string{"digest"})
+				_ = hs.AddSignature(hmac, r)
+				r.Header.Set("Digest", "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPA=")
+			},
+			want: ErrDigestMismatch,
+		},
+		{
+			name: "Tampered body covered by capitalized Digest header",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				hs.SetDefaultSignatureHeaders([]string{"Digest"})
+				_ = hs.AddSignature(hmac, r)
+				r.Body = ioutil.NopCloser(strings.NewReader(`{"hello": "tampered"}`))
+			},
+			want: ErrDigestMismatch,
+		},
+		{
+			name: "Invalid signature",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				_ = hs.SignMessage(hmac, r, MessageSignatureOptions{Components: []string{"date"}})
+				r.Header.Set("Date", "Tue, 20 Apr 2021 02:07:56 GMT")
+			},
+			want: ErrInvalidSignature,
+		},
+	}
+	sentinels := []error{
+		ErrMissingHeader, ErrMalformedHeader, ErrKeyNotFound, ErrWrongAlgorithm,
+		ErrPolicyViolation, ErrExpired, ErrDigestMismatch, ErrInvalidSignature,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			r := getMessageSignatureRequestFunc()
+			tt.sign(hs, r)
+			_, err := hs.Verify(r)
+			for _, s := range sentinels {
+				if got := errors.Is(err, s); got != (s == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorUnwrap(t *testing.T) {
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	r := getMessageSignatureRequestFunc()
+	r.Header.Set("Signature", `keyId="unknown",algorithm="hmac-sha256",signature="c2ln"`)
+	err := hs.VerifySignature(r)
+
+	var sErr *SecretError
+	if !errors.As(err, &sErr) {
+		t.Fatalf("errors.As(%v, *SecretError) = false", err)
+	}
+	if sErr.Message != "secret not found" {
+		t.Errorf("secret error message = %s", sErr.Message)
+	}
+
+	dErr := &DigestError{"wrong digest", withKind(&CryptoError{"wrong hash", nil}, ErrDigestMismatch)}
+	var cErr *CryptoError
+	if !errors.As(dErr, &cErr) || !errors.Is(dErr, ErrDigestMismatch) {
+		t.Errorf("digest error is expected to wrap crypto error & to be ErrDigestMismatch")
+	}
+	if got, want := dErr.Error(), "DigestError: wrong digest: CryptoError: wrong hash"; got != want {
+		t.Errorf("digest error message = %s, want %s", got, want)
+	}
+
+	err = &Error{"signature expired", withKind(nil, ErrExpired)}
+	if got := err.Error(); got != "signature expired" || !errors.Is(err, ErrExpired) {
+		t.Errorf("error %q is expected to keep its message & to be ErrExpired", got)
+	}
+	if err = (&Error{"unkeyed literal", nil}); errors.Is(err, ErrExpired) || err.Error() != "unkeyed literal" {
+		t.Errorf("error without kind = %v", err)
+	}
+}
@@ -4,21 +4,38 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"net/textproto"
 	"strings"
+	"sync"
 	"time"
+
+	"httpsignatures/sfv"
 )
 
 const (
-	signatureHeader     = "Signature"
-	// authorizationHeader = "Authorization"
-	requestTarget       = "(request-target)"
-	created             = "(created)"
-	expires             = "(expires)"
+	signatureHeader      = "Signature"
+	authorizationHeader  = "Authorization"
+	authorizationScheme  = "Signature"
+	digestHeader         = "Digest"
+	hostHeader           = "host"
+	forwardedHostHeader  = "X-Forwarded-Host"
+	requestTarget        = "(request-target)"
+	created              = "(created)"
+	expires              = "(expires)"
+	status               = "(status)"
+	defaultExpiresPeriod = 30 * time.Second
 )
 
+// timeNow current time to check signature expiration
+var timeNow = time.Now
+
+var defaultSignatureHeaders = []string{requestTarget, created, hostHeader}
+var defaultResponseSignatureHeaders = []string{status, created, "digest"}
+
 // Error errors during validating or creating Signature|Authorization
+// Kind of the error could be checked with errors.Is, e.g. errors.Is(err, ErrKeyNotFound)
 type Error struct {
 	Message string
 	Err     error
@@ -29,21 +46,38 @@ func (e *Error) Error() string {
 	if e == nil {
 		return ""
 	}
-	if e.Err != nil {
-		return e.Message + ": " + e.Err.Error()
+	if msg := errorMessage(e.Err); len(msg) > 0 {
+		return e.Message + ": " + msg
 	}
 	return e.Message
 }
 
+// Unwrap wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 // HTTPSignatures struct
+// Algorithms & policy could be changed at any time: HTTPSignatures is safe for concurrent use by multiple goroutines
 type HTTPSignatures struct {
-	ss  *SecretsStorage
-	d   *Digest
-	alg map[string]SignatureHashAlgorithm
+	mu              sync.RWMutex
+	ss              Secrets
+	d               *Digest
+	alg             map[string]SignatureHashAlgorithm
+	p               Policy
+	trustedProxies  []*net.IPNet
+	headers         []string
+	respHeaders     []string
+	expiresPeriod   time.Duration
+	digestAlgo      string
+	sfTypes         map[string]sfv.FieldType
+	strictParsing   bool
+	debug           bool
+	redactedHeaders []string
 }
 
 // NewHTTPSignatures Constructor
-func NewHTTPSignatures(ss *SecretsStorage) *HTTPSignatures {
+func NewHTTPSignatures(ss Secrets) *HTTPSignatures {
 	hs := new(HTTPSignatures)
 	hs.ss = ss
 	hs.d = NewDigest()
@@ -52,6 +86,15 @@ func NewHTTPSignatures(ss *SecretsStorage) *HTTPSignatures {
 		algoHmacSha256: HmacSha256{},
 		algoHmacSha512: HmacSha512{},
 	}
+	hs.headers = defaultSignatureHeaders
+	hs.respHeaders = defaultResponseSignatureHeaders
+	hs.expiresPeriod = defaultExpiresPeriod
+	hs.digestAlgo = algoSha256
+	hs.redactedHeaders = defaultRedactedHeaders
+	hs.sfTypes = make(map[string]sfv.FieldType, len(defaultStructuredFields))
+	for k, v := range defaultStructuredFields {
+		hs.sfTypes[k] = v
+	}
 	return hs
 }
 
@@ -62,108 +105,330 @@ func (hs *HTTPSignatures) SetDigestAlgorithm(a DigestHashAlgorithm) {
 
 // SetSignatureAlgorithm set custom signature hash algorithm
 func (hs *HTTPSignatures) SetSignatureAlgorithm(a SignatureHashAlgorithm) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
 	hs.alg[strings.ToUpper(a.Algorithm())] = a
 }
 
+// SetPolicy set verification policy (allowed digest & signature algorithms, minimal key size). Policy is copied:
+// changes of its lists made by the caller later don't affect verification
+func (hs *HTTPSignatures) SetPolicy(p Policy) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.p = p.clone()
+	hs.d.SetPolicy(p)
+}
+
+// SetTrustedProxies set list of trusted proxies (IP addresses or CIDR). Host of the request sent through trusted
+// proxy is taken from the right-most X-Forwarded-Host value
+func (hs *HTTPSignatures) SetTrustedProxies(proxies []string) error {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return &Error{fmt.Sprintf("wrong trusted proxy IP address '%s'", proxy), nil}
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return &Error{fmt.Sprintf("wrong trusted proxy CIDR '%s'", proxy), err}
+		}
+		nets = append(nets, n)
+	}
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.trustedProxies = nets
+	return nil
+}
+
+// SetDefaultSignatureHeaders set list of headers to sign (default: `(request-target) (created) host`)
+func (hs *HTTPSignatures) SetDefaultSignatureHeaders(headers []string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.headers = headers
+}
+
+// SetDefaultResponseSignatureHeaders set list of response headers to sign (default: `(status) (created) digest`)
+func (hs *HTTPSignatures) SetDefaultResponseSignatureHeaders(headers []string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.respHeaders = headers
+}
+
+// SetDefaultExpiresPeriod set period of signature validity used for `expires` param (default: 30 seconds)
+func (hs *HTTPSignatures) SetDefaultExpiresPeriod(d time.Duration) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.expiresPeriod = d
+}
+
+// SetDefaultDigestAlgorithm set digest hash algorithm used to create Digest header (default: SHA-256)
+func (hs *HTTPSignatures) SetDefaultDigestAlgorithm(a string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.digestAlgo = a
+}
+
+// SetStrictParsing reject draft-cavage Signature & Authorization headers not conforming to the draft ABNF
+// (default: lenient parsing, see Parser)
+func (hs *HTTPSignatures) SetStrictParsing(strict bool) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.strictParsing = strict
+}
+
+// acquireParser get parser in the configured mode from the pool
+func (hs *HTTPSignatures) acquireParser() *Parser {
+	hs.mu.RLock()
+	strict := hs.strictParsing
+	hs.mu.RUnlock()
+	return acquireParser(strict)
+}
+
 // VerifySignature Verify signature
 func (hs *HTTPSignatures) VerifySignature(r *http.Request) error {
+	_, err := hs.verifySignature(r.Header.Get(signatureHeader), requestMessage(r))
+	return err
+}
+
+// VerifyResponse verify signature of the response
+func (hs *HTTPSignatures) VerifyResponse(resp *http.Response) error {
+	_, err := hs.verifySignature(resp.Header.Get(signatureHeader), responseMessage(resp))
+	return err
+}
+
+// VerifyAuthorization verify authorization signature
+func (hs *HTTPSignatures) VerifyAuthorization(r *http.Request) error {
+	_, err := hs.verifyAuthorization(r)
+	return err
+}
+
+func (hs *HTTPSignatures) verifyAuthorization(r *http.Request) (VerificationResult, error) {
+	h, ok := hs.authorizationSignature(r)
+	if !ok {
+		return VerificationResult{}, &Error{
+			"authorization header with signature not found",
+			withKind(nil, ErrMissingHeader),
+		}
+	}
+	return hs.verifySignature(h, requestMessage(r))
+}
+
+func (hs *HTTPSignatures) verifySignature(h string, m message) (VerificationResult, error) {
+	var res VerificationResult
+
 	// Check signature header
-	h := r.Header.Get(signatureHeader)
 	if len(h) == 0 {
-		return &Error{"signature header not found", nil}
+		return res, &Error{"signature header not found", withKind(nil, ErrMissingHeader)}
 	}
 
 	// Parse header
-	p := NewParser()
+	p := hs.acquireParser()
+	defer releaseParser(p)
 	ph, pErr := p.ParseSignatureHeader(h)
 	if pErr != nil {
-		return pErr
+		return res, pErr
 	}
 
 	// Verify required fields in signature header
 	pErr = p.VerifySignatureFields()
 	if pErr != nil {
-		return pErr
+		return res, pErr
+	}
+
+	res.KeyID = ph.keyID
+	res.Algorithm = ph.algorithm
+	res.Components = ph.headers
+	res.Params = ph.Params()
+	if ph.created.Unix() > 0 {
+		res.Created = ph.created
+	}
+	if ph.expires.Unix() > 0 {
+		res.Expires = ph.expires
+	}
+
+	// Check expiration
+	if !res.Expires.IsZero() && res.Expires.Before(timeNow()) {
+		return res, &Error{"signature expired", withKind(nil, ErrExpired)}
 	}
 
 	// Check keyID & algorithm
 	secret, err := hs.ss.Get(ph.keyID)
 	if err != nil {
-		return &Error{fmt.Sprintf("keyID '%s' not found", ph.keyID), err}
+		return res, &Error{fmt.Sprintf("keyID '%s' not found", ph.keyID), withKind(err, ErrKeyNotFound)}
 	}
 	if !strings.EqualFold(secret.Algorithm, ph.algorithm) {
-		return &Error{
+		return res, &Error{
 			fmt.Sprintf("wrong algorithm '%s' for keyID '%s'", ph.algorithm, ph.keyID),
-			nil,
+			withKind(nil, ErrWrongAlgorithm),
 		}
 	}
-	alg, ok := hs.alg[strings.ToUpper(secret.Algorithm)]
+	res.Algorithm = secret.Algorithm
+	res.Metadata = secret.Metadata
+	alg, policy, ok := hs.algorithm(secret.Algorithm)
 	if !ok {
-		return &Error{
+		return res, &Error{
 			fmt.Sprintf("algorithm '%s' not supported", ph.algorithm),
-			nil,
+			withKind(nil, ErrWrongAlgorithm),
 		}
 	}
 
+	err = hs.verifyPolicy(policy, secret, alg)
+	if err != nil {
+		return res, err
+	}
+	err = hs.verifyRequiredParams(policy, res.Params)
+	if err != nil {
+		return res, err
+	}
+
 	// Verify digest
-	err = hs.verifyDigest(ph.headers, r)
+	err = hs.verifyDigest(ph.headers, m)
 	if err != nil {
-		return err
+		return res, err
 	}
 
 	// Create signature string
-	sigStr, err := hs.buildSignatureString(ph, r)
+	sigStr, err := hs.buildSignatureString(ph, m)
 	if err != nil {
-		return &Error{"build signature string error", err}
+		return res, &Error{"build signature string error", err}
 	}
 	if len(sigStr) == 0 {
-		return &Error{"empty string for signature", nil}
+		return res, &Error{"empty string for signature", nil}
 	}
+	res.SignatureString = hs.debugSignatureString(sigStr)
 
 	// Verify signature
 	signatureDecoded, err := base64.StdEncoding.DecodeString(ph.signature)
 	if err != nil {
-		return &Error{
+		return res, &Error{
 			"error decode signature from base64",
-			err,
+			withKind(err, ErrMalformedHeader),
 		}
 	}
 	err = alg.Verify(secret, sigStr, signatureDecoded)
 	if err != nil {
-		return &Error{"wrong signature", err}
+		if len(res.SignatureString) > 0 {
+			return res, &Error{
+				fmt.Sprintf("wrong signature, signature string %q", res.SignatureString),
+				withKind(err, ErrInvalidSignature),
+			}
+		}
+		return res, &Error{"wrong signature", withKind(err, ErrInvalidSignature)}
 	}
 
-	return nil
+	return res, nil
 }
 
-// VerifyAuthorization verify authorization signature
-func (hs *HTTPSignatures) VerifyAuthorization(r http.Request) error {
-
-	return nil
+// authorizationSignature signature params of the Authorization header with `Signature` scheme
+func (hs *HTTPSignatures) authorizationSignature(r *http.Request) (string, bool) {
+	h := strings.TrimSpace(r.Header.Get(authorizationHeader))
+	if len(h) <= len(authorizationScheme) || !strings.EqualFold(h[:len(authorizationScheme)], authorizationScheme) ||
+		h[len(authorizationScheme)] != ' ' {
+		return "", false
+	}
+	return strings.TrimSpace(h[len(authorizationScheme):]), true
 }
 
 // AddAuthorization add authorization header
-func (hs *HTTPSignatures) AddAuthorization(s Secret, r http.Request) error {
-
+func (hs *HTTPSignatures) AddAuthorization(s Secret, r *http.Request) error {
+	h, err := hs.createSignatureHeader(s, requestMessage(r))
+	if err != nil {
+		return err
+	}
+	r.Header.Set(authorizationHeader, authorizationScheme+" "+h)
 	return nil
 }
 
 // AddSignature add signature header
-func (hs *HTTPSignatures) AddSignature(s Secret, r http.Request) error {
-
+func (hs *HTTPSignatures) AddSignature(s Secret, r *http.Request) error {
+	h, err := hs.createSignatureHeader(s, requestMessage(r))
+	if err != nil {
+		return err
+	}
+	r.Header.Set(signatureHeader, h)
 	return nil
 }
 
-func (hs *HTTPSignatures) buildSignatureString(ph ParsedHeader, r *http.Request) ([]byte, error) {
+func (hs *HTTPSignatures) createSignatureHeader(s Secret, m message) (string, error) {
+	alg, _, ok := hs.algorithm(s.Algorithm)
+	hs.mu.RLock()
+	headers := hs.headers
+	if m.isResponse() {
+		headers = hs.respHeaders
+	}
+	expiresPeriod := hs.expiresPeriod
+	digestAlgo := hs.digestAlgo
+	hs.mu.RUnlock()
+	if !ok {
+		return "", &Error{
+			fmt.Sprintf("algorithm '%s' not supported", s.Algorithm),
+			withKind(nil, ErrWrongAlgorithm),
+		}
+	}
+
+	now := time.Unix(time.Now().Unix(), 0)
+	ph := ParsedHeader{
+		keyID:     s.KeyID,
+		algorithm: strings.ToLower(s.Algorithm),
+		created:   now,
+		headers:   headers,
+	}
+	for _, h := range headers {
+		switch strings.ToLower(h) {
+		case expires:
+			ph.expires = now.Add(expiresPeriod)
+		case strings.ToLower(digestHeader):
+			if len(m.header.Get(digestHeader)) > 0 {
+				continue
+			}
+			b, dErr := hs.d.readBody(m.body)
+			if dErr != nil {
+				return "", dErr
+			}
+			digest, err := hs.d.create(digestAlgo, b)
+			if err != nil {
+				return "", err
+			}
+			m.header.Set(digestHeader, digest)
+		}
+	}
+
+	sigStr, err := hs.buildSignatureString(ph, m)
+	if err != nil {
+		return "", &Error{"build signature string error", err}
+	}
+	sig, err := alg.Create(s, sigStr)
+	if err != nil {
+		return "", &Error{"error creating signature", err}
+	}
+
+	ph.signature = base64.StdEncoding.EncodeToString(sig)
+	return SerializeSignatureHeader(ph)
+}
+
+func (hs *HTTPSignatures) buildSignatureString(ph ParsedHeader, m message) ([]byte, error) {
 	j := len(ph.headers)
-	headers := r.Header.Clone()
 	var b bytes.Buffer
 	for i, h := range ph.headers {
-		switch h {
+		switch strings.ToLower(h) {
 		case requestTarget:
+			if m.isResponse() {
+				return nil, &Error{fmt.Sprintf("param '%s' is not available for response", requestTarget), nil}
+			}
 			// 2.3.1 Note: For the avoidance of doubt, lowercasing only applies to the :method pseudo-header
 			// and not to the :path pseudo-header.
+			r := m.request
 			b.WriteString(fmt.Sprintf("%s: %s %s", requestTarget, strings.ToLower(r.Method), r.URL.RequestURI()))
+		case status:
+			if !m.isResponse() {
+				return nil, &Error{fmt.Sprintf("param '%s' is available for response only", status), nil}
+			}
+			b.WriteString(fmt.Sprintf("%s: %d", status, m.status))
 		case created:
 			if hs.isAlgoHasPrefix(ph.algorithm) && j == 1 {
 				// 2.3.2 If the header field name is `(created)` and the `algorithm`  parameter starts with
@@ -173,13 +438,13 @@ func (hs *HTTPSignatures) buildSignatureString(ph ParsedHeader, r *http.Request)
 					nil,
 				}
 			}
-			if ph.created == time.Unix(0, 0) {
+			if ph.created.Unix() <= 0 {
 				return nil, &Error{
 					fmt.Sprintf("param '%s', required in signature, not found", created),
-					nil,
+					withKind(nil, ErrMissingHeader),
 				}
 			}
-			b.WriteString(fmt.Sprintf("%s: %d", created, ph.created.Unix()))
+			b.WriteString(fmt.Sprintf("%s: %s", created, formatTimestamp(ph.created)))
 		case expires:
 			if hs.isAlgoHasPrefix(ph.algorithm) && j == 1 {
 				// 2.3.3 If the header field name is `(expires)` and the `algorithm` parameter starts with
@@ -189,26 +454,37 @@ func (hs *HTTPSignatures) buildSignatureString(ph ParsedHeader, r *http.Request)
 					nil,
 				}
 			}
-			if ph.expires == time.Unix(0, 0) {
+			if ph.expires.Unix() <= 0 {
 				return nil, &Error{
 					fmt.Sprintf("param '%s', required in signature, not found", expires),
-					nil,
+					withKind(nil, ErrMissingHeader),
 				}
 			}
-			b.WriteString(fmt.Sprintf("%s: %d", expires, ph.expires.Unix()))
-		default:
-			// 2.3.4 Leading and trailing optional whitespace (OWS) in the header field value MUST be omitted
-			// 2.3.4.2 If the header value (after removing leading and trailing whitespace) is a zero-length string,
-			// the signature string line correlating with that header will simply be the (lowercased) header name,
-			// an ASCII colon `:`, and an ASCII space ` `.
-			reqHeader, ok := headers[textproto.CanonicalMIMEHeaderKey(h)]
-			if !ok {
+			b.WriteString(fmt.Sprintf("%s: %s", expires, formatTimestamp(ph.expires)))
+		case hostHeader:
+			if m.isResponse() {
+				v, err := hs.signatureStringHeader(h, m.header)
+				if err != nil {
+					return nil, err
+				}
+				b.WriteString(v)
+				break
+			}
+			// Go moves Host header to the Request.Host field (both on client & server sides)
+			host := hs.host(m.request)
+			if len(host) == 0 {
 				return nil, &Error{
 					fmt.Sprintf("header '%s', required in signature, not found", h),
-					nil,
+					withKind(nil, ErrMissingHeader),
 				}
 			}
-			b.WriteString(fmt.Sprintf("%s: %s", strings.ToLower(h), strings.TrimSpace(reqHeader[0])))
+			b.WriteString(fmt.Sprintf("%s: %s", hostHeader, host))
+		default:
+			v, err := hs.signatureStringHeader(h, m.header)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(v)
 		}
 		if i < j-1 {
 			b.WriteString("\n")
@@ -218,6 +494,99 @@ func (hs *HTTPSignatures) buildSignatureString(ph ParsedHeader, r *http.Request)
 	return b.Bytes(), nil
 }
 
+// signatureStringHeader signature string line of the message header
+func (hs *HTTPSignatures) signatureStringHeader(h string, header http.Header) (string, error) {
+	// 2.3.4.2 If the header value (after removing leading and trailing whitespace) is a zero-length string,
+	// the signature string line correlating with that header will simply be the (lowercased) header name,
+	// an ASCII colon `:`, and an ASCII space ` `.
+	values, ok := header[textproto.CanonicalMIMEHeaderKey(h)]
+	if !ok {
+		return "", &Error{
+			fmt.Sprintf("header '%s', required in signature, not found", h),
+			withKind(nil, ErrMissingHeader),
+		}
+	}
+	return fmt.Sprintf("%s: %s", strings.ToLower(h), hs.headerValue(values)), nil
+}
+
+// host value of the Host header: X-Forwarded-Host for requests from trusted proxies, r.Host or r.URL.Host
+func (hs *HTTPSignatures) host(r *http.Request) string {
+	if fh := forwardedValue(r.Header, forwardedHostHeader); len(fh) > 0 && hs.isTrustedProxy(r.RemoteAddr) {
+		return fh
+	}
+	if len(r.Host) > 0 {
+		return r.Host
+	}
+	if r.URL != nil && len(r.URL.Host) > 0 {
+		return r.URL.Host
+	}
+	return hs.headerValue(r.Header["Host"])
+}
+
+// forwardedValue right-most value of X-Forwarded-* header. Proxies append values to the list: the last one is set by
+// the trusted proxy, the others might be forged by the client
+func forwardedValue(h http.Header, name string) string {
+	values := h[name]
+	if len(values) == 0 {
+		return ""
+	}
+	v := values[len(values)-1]
+	return strings.TrimSpace(v[strings.LastIndex(v, ",")+1:])
+}
+
+func (hs *HTTPSignatures) isTrustedProxy(remoteAddr string) bool {
+	hs.mu.RLock()
+	proxies := hs.trustedProxies
+	hs.mu.RUnlock()
+	if len(proxies) == 0 {
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerValue canonical value of the header which could be repeated in the message
+// 2.3.4 If there are multiple instances of a header field, all header field values associated with the header field
+// MUST be concatenated, separated by a ASCII comma and an ASCII space `, `, and used in the order in which they will
+// appear in the transmitted HTTP message.
+func (hs *HTTPSignatures) headerValue(values []string) string {
+	v := make([]string, len(values))
+	for i, value := range values {
+		v[i] = hs.unfoldHeaderValue(value)
+	}
+	return strings.Join(v, ", ")
+}
+
+// unfoldHeaderValue remove leading & trailing whitespaces, replace obsolete line folding (obs-fold) with single space
+// 2.3.4 Leading and trailing optional whitespace (OWS) in the header field value MUST be omitted
+func (hs *HTTPSignatures) unfoldHeaderValue(value string) string {
+	if !strings.ContainsAny(value, "\r\n") {
+		return strings.TrimSpace(value)
+	}
+	lines := strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n")
+	v := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) > 0 {
+			v = append(v, line)
+		}
+	}
+	return strings.Join(v, " ")
+}
+
 func (hs *HTTPSignatures) isAlgoHasPrefix(algo string) bool {
 	a := []string{`rsa`, `hmac`, `ecdsa`}
 	algo = strings.ToLower(algo)
@@ -230,10 +599,56 @@ func (hs *HTTPSignatures) isAlgoHasPrefix(algo string) bool {
 	return false
 }
 
-func (hs *HTTPSignatures) verifyDigest(ph []string, r *http.Request) error {
+// algorithm get signature hash algorithm by name & current policy
+func (hs *HTTPSignatures) algorithm(name string) (SignatureHashAlgorithm, Policy, bool) {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	alg, ok := hs.alg[strings.ToUpper(name)]
+	return alg, hs.p, ok
+}
+
+func (hs *HTTPSignatures) verifyPolicy(p Policy, secret Secret, alg SignatureHashAlgorithm) error {
+	if !p.isSignatureAlgorithmAllowed(secret.Algorithm) {
+		return &Error{
+			fmt.Sprintf("algorithm '%s' not allowed by policy", secret.Algorithm),
+			withKind(nil, ErrPolicyViolation),
+		}
+	}
+
+	ks, ok := alg.(KeySizer)
+	if p.MinRSAKeySize == 0 || !ok || !strings.HasPrefix(strings.ToLower(secret.Algorithm), "rsa") {
+		return nil
+	}
+	size, err := ks.KeySize(secret)
+	if err != nil {
+		return &Error{"error reading key size", err}
+	}
+	if size < p.MinRSAKeySize {
+		return &Error{
+			fmt.Sprintf("key size %d bits for keyID '%s' is less than %d bits", size, secret.KeyID, p.MinRSAKeySize),
+			withKind(nil, ErrPolicyViolation),
+		}
+	}
+	return nil
+}
+
+// verifyRequiredParams check all extension params required by the policy are set in the signature
+func (hs *HTTPSignatures) verifyRequiredParams(p Policy, params map[string]string) error {
+	for _, name := range p.RequiredParams {
+		if _, ok := params[name]; !ok {
+			return &Error{
+				fmt.Sprintf("param '%s' required by policy not found", name),
+				withKind(nil, ErrPolicyViolation),
+			}
+		}
+	}
+	return nil
+}
+
+func (hs *HTTPSignatures) verifyDigest(ph []string, m message) error {
 	for _, h := range ph {
-		if h == "digest" {
-			err := hs.d.Verify(r)
+		if strings.EqualFold(h, digestHeader) {
+			err := hs.d.verify(m.header, m.body)
 			if err != nil {
 				return err
 			}
@@ -2,8 +2,10 @@ package httpsignatures
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -153,6 +155,42 @@ func TestBuildSignatureString(t *testing.T) {
 			wantErrType: httpsignaturesErrType,
 			wantErrMsg:  "",
 		},
+		{
+			name: "Decimal created & expires",
+			args: args{
+				ph: ParsedHeader{
+					algorithm: "md5",
+					headers:   []string{"(created)", "(expires)"},
+					created:   time.Unix(1402170695, 0),
+					expires:   time.Unix(1402170995, 250000000),
+				},
+				r: (func() *http.Request {
+					r, _ := http.NewRequest(http.MethodPost, httpsignaturesHostExample, nil)
+					return r
+				})(),
+			},
+			want: []byte("(created): 1402170695\n" +
+				"(expires): 1402170995.25"),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Created is not set",
+			args: args{
+				ph: ParsedHeader{
+					algorithm: "md5",
+					headers:   []string{"(created)", "(expires)"},
+					expires:   time.Unix(1402170995, 0),
+				},
+				r: (func() *http.Request {
+					r, _ := http.NewRequest(http.MethodPost, httpsignaturesHostExample, nil)
+					return r
+				})(),
+			},
+			want:        nil,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "param '(created)', required in signature, not found",
+		},
 		{
 			name: "Has only created header in signature & rsa algorithm",
 			args: args{
@@ -276,13 +314,90 @@ func TestBuildSignatureString(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			hs := NewHTTPSignatures(ss)
-			got, err := hs.buildSignatureString(tt.args.ph, tt.args.r)
+			got, err := hs.buildSignatureString(tt.args.ph, requestMessage(tt.args.r))
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestBuildSignatureStringMultipleValues(t *testing.T) {
+	ss := NewSecretsStorage(map[string]Secret{})
+	type args struct {
+		ph ParsedHeader
+		r  *http.Request
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        []byte
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Draft example: repeated, empty & folded headers",
+			args: args{
+				ph: ParsedHeader{
+					algorithm: "hs2019",
+					headers: []string{
+						"(request-target)",
+						"(created)",
+						"host",
+						"date",
+						"cache-control",
+						"x-emptyheader",
+						"x-example",
+					},
+					created: time.Unix(1402170695, 0),
+				},
+				r: (func() *http.Request {
+					r, _ := http.NewRequest(http.MethodGet, httpsignaturesHostExample, nil)
+					r.Header.Set("Host", "example.org")
+					r.Header.Set("Date", "Tue, 07 Jun 2014 20:51:35 GMT")
+					r.Header.Set("X-Example", "Example header\r\n    with some whitespace.   ")
+					r.Header.Set("X-EmptyHeader", "")
+					r.Header.Add("Cache-Control", "max-age=60")
+					r.Header.Add("Cache-Control", "must-revalidate")
+					return r
+				})(),
+			},
+			want: []byte("(request-target): get /foo\n" +
+				"(created): 1402170695\n" +
+				"host: example.org\n" +
+				"date: Tue, 07 Jun 2014 20:51:35 GMT\n" +
+				"cache-control: max-age=60, must-revalidate\n" +
+				"x-emptyheader: \n" +
+				"x-example: Example header with some whitespace."),
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Repeated header values with whitespaces",
+			args: args{
+				ph: ParsedHeader{
+					algorithm: "hs2019",
+					headers:   []string{"x-forwarded-for"},
+				},
+				r: (func() *http.Request {
+					r, _ := http.NewRequest(http.MethodGet, httpsignaturesHostExample, nil)
+					r.Header.Add("X-Forwarded-For", " 192.0.2.1 ")
+					r.Header.Add("X-Forwarded-For", "198.51.100.2,\n\t203.0.113.3")
+					return r
+				})(),
+			},
+			want:        []byte("x-forwarded-for: 192.0.2.1, 198.51.100.2, 203.0.113.3"),
+			wantErrType: httpsignaturesErrType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(ss)
+			got, err := hs.buildSignatureString(tt.args.ph, requestMessage(tt.args.r))
 			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
 		})
 	}
 }
 
 func TestVerifySignature(t *testing.T) {
+	defer setTimeNow(1402170695)()
 	ss := NewSecretsStorage(map[string]Secret{
 		"Test": {
 			KeyID:      "Test",
@@ -403,3 +518,447 @@ func TestVerifySignature(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifySignatureConcurrent(t *testing.T) {
+	defer setTimeNow(1402170695)()
+	ss := NewSecretsStorage(map[string]Secret{
+		"Test": {
+			KeyID:      "Test",
+			PrivateKey: rsaPrivateKey,
+			PublicKey:  rsaPublicKey,
+			Algorithm:  "RSA-SHA256",
+		},
+	})
+	hs := NewHTTPSignatures(ss)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r, _ := http.NewRequest(
+				http.MethodPost,
+				httpsignaturesHostExampleFull,
+				strings.NewReader(httpsignaturesBodyExample))
+			r.Header.Set("Signature", `keyId="Test",algorithm="rsa-sha256",created=1402170695,expires=1402170699,headers="(request-target) (created) (expires) host date content-type digest content-length",signature="nAkCW0wg9AbbStQRLi8fsS1mPPnA6S5+/0alANcoDFG9hG0bJ8NnMRcB1Sz1eccNMzzLEke7nGXqoiJYZFfT81oaRqh/MNFwQVX4OZvTLZ5xVZQuchRkOSO7b2QX0aFWFOUq6dnwAyliHrp6w3FOxwkGGJPaerw2lOYLdC/Bejk="`)
+			r.Header.Set("Host", "example.com")
+			r.Header.Set("Date", "Sun, 05 Jan 2014 21:31:40 GMT")
+			r.Header.Set("Digest", "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("Content-length", "18")
+			if err := hs.VerifySignature(r); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			hs.SetSignatureAlgorithm(HmacSha512{})
+			hs.SetDigestAlgorithm(Sha512{})
+			hs.SetPolicy(Policy{})
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSignAndVerify(t *testing.T) {
+	secret := Secret{
+		KeyID:      "Test",
+		PrivateKey: rsaPrivateKey,
+		PublicKey:  rsaPublicKey,
+		Algorithm:  "RSA-SHA256",
+	}
+	hs := NewHTTPSignatures(NewSecretsStorage(map[string]Secret{"Test": secret}))
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "(expires)", "date", "digest"})
+
+	tests := []struct {
+		name string
+		sign func(r *http.Request) error
+	}{
+		{
+			name: "Signature header",
+			sign: func(r *http.Request) error {
+				return hs.AddSignature(secret, r)
+			},
+		},
+		{
+			name: "Authorization header",
+			sign: func(r *http.Request) error {
+				err := hs.AddAuthorization(secret, r)
+				if !strings.HasPrefix(r.Header.Get("Authorization"), "Signature keyId=") {
+					t.Errorf("wrong authorization header %s", r.Header.Get("Authorization"))
+				}
+				r.Header.Set("Signature", strings.TrimPrefix(r.Header.Get("Authorization"), "Signature "))
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodPost, httpsignaturesHostExample, strings.NewReader(httpsignaturesBodyExample))
+			r.Header.Set("Date", "Sun, 05 Jan 2014 21:31:40 GMT")
+			if err := tt.sign(r); err != nil {
+				t.Fatalf("sign error: %s", err)
+			}
+			if got := r.Header.Get("Digest"); got != "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=" {
+				t.Errorf("digest header = %s", got)
+			}
+			if err := hs.VerifySignature(r); err != nil {
+				t.Errorf("verify error: %s, header: %s", err, r.Header.Get("Signature"))
+			}
+		})
+	}
+}
+
+func TestSignAndVerifyHost(t *testing.T) {
+	secret := Secret{
+		KeyID:      "Test",
+		PrivateKey: rsaPrivateKey,
+		PublicKey:  rsaPublicKey,
+		Algorithm:  "RSA-SHA256",
+	}
+	hs := NewHTTPSignatures(NewSecretsStorage(map[string]Secret{"Test": secret}))
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "(expires)", "host", "digest"})
+
+	var verifyErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Header["Host"]; ok {
+			t.Error("host header expected to be moved to Request.Host")
+		}
+		verifyErr = hs.VerifySignature(r)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		sign func(r *http.Request) error
+	}{
+		{
+			name: "Signature header",
+			sign: func(r *http.Request) error {
+				return hs.AddSignature(secret, r)
+			},
+		},
+		{
+			name: "Authorization header",
+			sign: func(r *http.Request) error {
+				err := hs.AddAuthorization(secret, r)
+				r.Header.Set("Signature", strings.TrimPrefix(r.Header.Get("Authorization"), "Signature "))
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodPost, srv.URL+"/foo?param=value", strings.NewReader(httpsignaturesBodyExample))
+			if err := tt.sign(r); err != nil {
+				t.Fatalf("sign error: %s", err)
+			}
+			resp, err := srv.Client().Do(r)
+			if err != nil {
+				t.Fatalf("request error: %s", err)
+			}
+			_ = resp.Body.Close()
+			if verifyErr != nil {
+				t.Errorf("verify error: %s", verifyErr)
+			}
+		})
+	}
+}
+
+func TestHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		r       *http.Request
+		want    string
+	}{
+		{
+			name: "Client request",
+			r: (func() *http.Request {
+				r, _ := http.NewRequest(http.MethodGet, httpsignaturesHostExample, nil)
+				return r
+			})(),
+			want: "example.org",
+		},
+		{
+			name: "Server request",
+			r: (func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+				r.Host = "example.com:8080"
+				return r
+			})(),
+			want: "example.com:8080",
+		},
+		{
+			name: "Empty Request.Host",
+			r: (func() *http.Request {
+				r, _ := http.NewRequest(http.MethodGet, httpsignaturesHostExample, nil)
+				r.Host = ""
+				return r
+			})(),
+			want: "example.org",
+		},
+		{
+			name: "Host header only",
+			r: (func() *http.Request {
+				r, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+				r.Header.Set("Host", "example.net")
+				return r
+			})(),
+			want: "example.net",
+		},
+		{
+			name:    "X-Forwarded-Host from trusted proxy",
+			proxies: []string{"10.0.0.0/8", "192.0.2.1"},
+			r: (func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+				r.RemoteAddr = "192.0.2.1:1234"
+				r.Header.Set("X-Forwarded-Host", "example.com")
+				return r
+			})(),
+			want: "example.com",
+		},
+		{
+			name:    "X-Forwarded-Host forged by client through appending proxy",
+			proxies: []string{"192.0.2.1"},
+			r: (func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+				r.RemoteAddr = "192.0.2.1:1234"
+				r.Header.Set("X-Forwarded-Host", "evil.example, example.net")
+				return r
+			})(),
+			want: "example.net",
+		},
+		{
+			name:    "X-Forwarded-Host forged by client in separate header line",
+			proxies: []string{"192.0.2.1"},
+			r: (func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+				r.RemoteAddr = "192.0.2.1:1234"
+				r.Header.Add("X-Forwarded-Host", "evil.example")
+				r.Header.Add("X-Forwarded-Host", "example.net")
+				return r
+			})(),
+			want: "example.net",
+		},
+		{
+			name:    "X-Forwarded-Host from untrusted proxy",
+			proxies: []string{"10.0.0.0/8"},
+			r: (func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+				r.RemoteAddr = "192.0.2.1:1234"
+				r.Header.Set("X-Forwarded-Host", "example.com")
+				return r
+			})(),
+			want: "example.com",
+		},
+		{
+			name: "X-Forwarded-Host without trusted proxies",
+			r: (func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+				r.Header.Set("X-Forwarded-Host", "example.net")
+				return r
+			})(),
+			want: "example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(NewSecretsStorage(map[string]Secret{}))
+			if err := hs.SetTrustedProxies(tt.proxies); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got := hs.host(tt.r)
+			if got != tt.want {
+				t.Errorf(tt.name+"\ngot  = %v,\nwant = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name        string
+		proxies     []string
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:        "Valid proxies",
+			proxies:     []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Wrong IP",
+			proxies:     []string{"192.0.2"},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong trusted proxy IP address '192.0.2'",
+		},
+		{
+			name:        "Wrong CIDR",
+			proxies:     []string{"192.0.2.0/33"},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong trusted proxy CIDR '192.0.2.0/33': invalid CIDR address: 192.0.2.0/33",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(NewSecretsStorage(map[string]Secret{}))
+			err := hs.SetTrustedProxies(tt.proxies)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestVerifyAuthorization(t *testing.T) {
+	secret, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	tests := []struct {
+		name        string
+		header      func(h string) string
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Valid authorization",
+			header: func(h string) string {
+				return h
+			},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Lowercased scheme",
+			header: func(h string) string {
+				return "signature" + strings.TrimPrefix(h, "Signature")
+			},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Other scheme",
+			header: func(h string) string {
+				return "Signatures" + strings.TrimPrefix(h, "Signature")
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "authorization header with signature not found",
+		},
+		{
+			name: "Empty signature params",
+			header: func(h string) string {
+				return "Signature "
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "authorization header with signature not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			r := getMessageSignatureRequestFunc()
+			if err := hs.AddAuthorization(secret, r); err != nil {
+				t.Fatalf("sign error: %s", err)
+			}
+			r.Header.Set("Authorization", tt.header(r.Header.Get("Authorization")))
+			err := hs.VerifyAuthorization(r)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestSignAndVerifyDecimalExpires(t *testing.T) {
+	secret, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "(expires)", "host"})
+	hs.SetDefaultExpiresPeriod(1500 * time.Millisecond)
+	r := getMessageSignatureRequestFunc()
+	if err := hs.AddSignature(secret, r); err != nil {
+		t.Fatalf("sign error: %s", err)
+	}
+	if h := r.Header.Get("Signature"); !strings.Contains(h, ".5,headers=") {
+		t.Errorf("signature header %s, expected decimal expires", h)
+	}
+	res, err := hs.Verify(r)
+	if err != nil {
+		t.Fatalf("verify error: %s", err)
+	}
+	if d := res.Expires.Sub(res.Created); d != 1500*time.Millisecond {
+		t.Errorf("expires - created = %s, want 1.5s", d)
+	}
+}
+
+func TestSetStrictParsing(t *testing.T) {
+	tests := []struct {
+		name        string
+		strict      bool
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:        "Lenient parsing",
+			strict:      false,
+			want:        true,
+			wantErrType: parserErrType,
+		},
+		{
+			name:        "Strict parsing",
+			strict:      true,
+			want:        false,
+			wantErrType: parserErrType,
+			wantErrMsg:  "ParserError: keyId is not set in header",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, _ := messageSignaturesSecrets.Get("test-shared-secret")
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			hs.SetStrictParsing(tt.strict)
+			r := getMessageSignatureRequestFunc()
+			_ = hs.AddSignature(secret, r)
+			r.Header.Set("Signature", strings.Replace(r.Header.Get("Signature"), "keyId=", "keyid=", 1))
+			err := hs.VerifySignature(r)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func BenchmarkVerifySignature(b *testing.B) {
+	secret, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "date", "content-type"})
+	r := getMessageSignatureRequestFunc()
+	if err := hs.AddSignature(secret, r); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := hs.VerifySignature(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSignKeyIDControlCharacters(t *testing.T) {
+	secret := Secret{KeyID: "key\nid", PrivateKey: "test-secret", Algorithm: algoHmacSha256}
+	hs := NewHTTPSignatures(NewSecretsStorage(map[string]Secret{secret.KeyID: secret}))
+	r := getMessageSignatureRequestFunc()
+	err := hs.AddSignature(secret, r)
+	assert(t, err == nil, err, httpsignaturesErrType, "Control characters", false,
+		"param 'keyId' value must not contain control characters")
+}
+
+// setTimeNow freeze current time to verify test vectors with expires param, returns func restoring it
+func setTimeNow(unix int64) func() {
+	timeNow = func() time.Time { return time.Unix(unix, 0) }
+	return func() { timeNow = time.Now }
+}
@@ -0,0 +1,27 @@
+package httpsignatures
+
+import (
+	"io"
+	"net/http"
+)
+
+// message HTTP request or response to sign or verify
+// request is the signed request itself or the request which triggered the signed response
+type message struct {
+	header  http.Header
+	body    *io.ReadCloser
+	request *http.Request
+	status  int
+}
+
+func requestMessage(r *http.Request) message {
+	return message{header: r.Header, body: &r.Body, request: r}
+}
+
+func responseMessage(resp *http.Response) message {
+	return message{header: resp.Header, body: &resp.Body, request: resp.Request, status: resp.StatusCode}
+}
+
+func (m message) isResponse() bool {
+	return m.status != 0
+}
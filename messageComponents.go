@@ -0,0 +1,269 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"httpsignatures/sfv"
+)
+
+// RFC 9421 derived components (2.2)
+const (
+	methodComponent        = "@method"
+	targetURIComponent     = "@target-uri"
+	authorityComponent     = "@authority"
+	schemeComponent        = "@scheme"
+	requestTargetComponent = "@request-target"
+	pathComponent          = "@path"
+	queryComponent         = "@query"
+	queryParamComponent    = "@query-param"
+	statusComponent        = "@status"
+	forwardedProtoHeader   = "X-Forwarded-Proto"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// defaultStructuredFields types of known structured fields used with `sf` & `key` component parameters
+var defaultStructuredFields = map[string]sfv.FieldType{
+	"accept-signature":    sfv.DictionaryType,
+	"cache-status":        sfv.ListType,
+	"content-digest":      sfv.DictionaryType,
+	"priority":            sfv.DictionaryType,
+	"proxy-status":        sfv.ListType,
+	"repr-digest":         sfv.DictionaryType,
+	"signature":           sfv.DictionaryType,
+	"signature-input":     sfv.DictionaryType,
+	"want-content-digest": sfv.DictionaryType,
+	"want-repr-digest":    sfv.DictionaryType,
+}
+
+// SetStructuredField set type of the structured field header to sign it with `sf` component parameter
+func (hs *HTTPSignatures) SetStructuredField(name string, t sfv.FieldType) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.sfTypes[strings.ToLower(name)] = t
+}
+
+// structuredFieldValue strictly serialized structured field value (2.1.1) or dictionary member value (2.1.2)
+func (hs *HTTPSignatures) structuredFieldValue(name string, params sfv.Params, values []string) (string, error) {
+	var key string
+	hasKey := false
+	for _, p := range params {
+		switch p.Key {
+		case "sf":
+			if p.Value != true {
+				return "", &Error{"component parameter 'sf' must be boolean true", nil}
+			}
+		case "key":
+			k, ok := p.Value.(string)
+			if !ok {
+				return "", &Error{"component parameter 'key' must be a string", nil}
+			}
+			key, hasKey = k, true
+		default:
+			return "", &Error{fmt.Sprintf("component parameter '%s' not supported", p.Key), nil}
+		}
+	}
+
+	hs.mu.RLock()
+	t, ok := hs.sfTypes[name]
+	hs.mu.RUnlock()
+	if hasKey {
+		// 2.1.2 If the value of the field is not a Dictionary, this MUST cause an error
+		if ok && t != sfv.DictionaryType {
+			return "", &Error{fmt.Sprintf("header '%s' is not a dictionary", name), nil}
+		}
+		t, ok = sfv.DictionaryType, true
+	}
+	if !ok {
+		return "", &Error{fmt.Sprintf("unknown structured field type of the header '%s'", name), nil}
+	}
+
+	v, err := sfv.Parse(t, strings.Join(values, ", "))
+	if err != nil {
+		return "", &Error{fmt.Sprintf("wrong structured field header '%s'", name), err}
+	}
+	if !hasKey {
+		return sfv.Serialize(v)
+	}
+
+	m, ok := v.(sfv.Dictionary).Get(key)
+	if !ok {
+		return "", &Error{fmt.Sprintf("key '%s' not found in header '%s'", key, name), nil}
+	}
+	if l, ok := m.(sfv.InnerList); ok {
+		return sfv.SerializeInnerList(l)
+	}
+	return sfv.SerializeItem(m.(sfv.Item))
+}
+
+// derivedComponentValue value of the derived component (2.2)
+func (hs *HTTPSignatures) derivedComponentValue(name string, params sfv.Params, m message) (string, error) {
+	for _, p := range params {
+		if name != queryParamComponent || p.Key != "name" {
+			return "", &Error{fmt.Sprintf("component parameter '%s' not supported", p.Key), nil}
+		}
+	}
+
+	switch name {
+	case statusComponent:
+		// 2.2.9 The @status component identifier MUST NOT be used in a request message
+		if !m.isResponse() {
+			return "", &Error{fmt.Sprintf("component '%s' is available for response only", name), nil}
+		}
+		return strconv.Itoa(m.status), nil
+	case signatureParamsComponent:
+		return "", &Error{fmt.Sprintf("component '%s' must not be covered", name), nil}
+	}
+
+	// Request derived components are not available for response
+	if m.isResponse() {
+		return "", &Error{fmt.Sprintf("component '%s' is not available for response", name), nil}
+	}
+	r := m.request
+	switch name {
+	case methodComponent:
+		// 2.2.1 The method name is case-sensitive and is not normalized
+		if len(r.Method) == 0 {
+			return http.MethodGet, nil
+		}
+		return r.Method, nil
+	case targetURIComponent:
+		return hs.targetURI(r), nil
+	case authorityComponent:
+		return hs.authority(r), nil
+	case schemeComponent:
+		return hs.scheme(r), nil
+	case requestTargetComponent:
+		return r.URL.RequestURI(), nil
+	case pathComponent:
+		return hs.path(r), nil
+	case queryComponent:
+		// 2.2.7 If the query string is absent from the request message, the component value is the leading `?`
+		return "?" + r.URL.RawQuery, nil
+	case queryParamComponent:
+		return hs.queryParam(params, r)
+	default:
+		return "", &Error{fmt.Sprintf("derived component '%s' not supported", name), nil}
+	}
+}
+
+// targetURI full target URI of the request (2.2.2)
+func (hs *HTTPSignatures) targetURI(r *http.Request) string {
+	u := url.URL{
+		Scheme:   hs.scheme(r),
+		Host:     hs.authority(r),
+		Path:     r.URL.Path,
+		RawPath:  r.URL.RawPath,
+		RawQuery: r.URL.RawQuery,
+	}
+	if len(u.Path) == 0 {
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+// authority host & port of the request (2.2.3). Host is lowercased, default port is omitted
+func (hs *HTTPSignatures) authority(r *http.Request) string {
+	authority := strings.ToLower(hs.host(r))
+	host, port, err := net.SplitHostPort(authority)
+	if err != nil || defaultPorts[hs.scheme(r)] != port {
+		return authority
+	}
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// scheme lowercased URI scheme of the request (2.2.4): right-most X-Forwarded-Proto value for requests from trusted
+// proxies, r.URL.Scheme on the client side or TLS connection state on the server side
+func (hs *HTTPSignatures) scheme(r *http.Request) string {
+	if fp := forwardedValue(r.Header, forwardedProtoHeader); len(fp) > 0 && hs.isTrustedProxy(r.RemoteAddr) {
+		return strings.ToLower(fp)
+	}
+	if len(r.URL.Scheme) > 0 {
+		return strings.ToLower(r.URL.Scheme)
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// path absolute path of the request (2.2.6). Empty path is normalized to `/`
+func (hs *HTTPSignatures) path(r *http.Request) string {
+	p := r.URL.EscapedPath()
+	if len(p) == 0 {
+		return "/"
+	}
+	return p
+}
+
+// queryParam value of the named query parameter (2.2.8)
+func (hs *HTTPSignatures) queryParam(params sfv.Params, r *http.Request) (string, error) {
+	v, _ := params.Get("name")
+	name, ok := v.(string)
+	if !ok {
+		return "", &Error{fmt.Sprintf("component '%s' requires 'name' parameter", queryParamComponent), nil}
+	}
+
+	var value string
+	found := false
+	for _, pair := range strings.Split(r.URL.RawQuery, "&") {
+		if len(pair) == 0 {
+			continue
+		}
+		k, val := pair, ""
+		if i := strings.IndexByte(pair, '='); i != -1 {
+			k, val = pair[:i], pair[i+1:]
+		}
+		k, err := url.QueryUnescape(k)
+		if err != nil {
+			return "", &Error{"wrong query string", err}
+		}
+		if hs.encodeQueryParam(k) != name {
+			continue
+		}
+		if found {
+			// 2.2.8 If a parameter name occurs multiple times in a request, the named parameter MUST NOT be included
+			return "", &Error{fmt.Sprintf("query parameter '%s' occurs multiple times", name), nil}
+		}
+		if val, err = url.QueryUnescape(val); err != nil {
+			return "", &Error{"wrong query string", err}
+		}
+		value = hs.encodeQueryParam(val)
+		found = true
+	}
+	if !found {
+		return "", &Error{
+			fmt.Sprintf("query parameter '%s', required in signature, not found", name),
+			withKind(nil, ErrMissingHeader),
+		}
+	}
+
+	return value, nil
+}
+
+// encodeQueryParam percent-encode query parameter name or value (2.2.8): application/x-www-form-urlencoded
+// percent-encode set is used, spaces are encoded as `%20`
+func (hs *HTTPSignatures) encodeQueryParam(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+			c == '*' || c == '-' || c == '.' || c == '_' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%%%02X", c))
+	}
+	return b.String()
+}
@@ -0,0 +1,373 @@
+package httpsignatures
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"httpsignatures/sfv"
+)
+
+func TestDerivedComponentValue(t *testing.T) {
+	clientRequest := func(method string, target string) *http.Request {
+		r, _ := http.NewRequest(method, target, nil)
+		return r
+	}
+	serverRequest := func(target string, host string, secure bool) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Host = host
+		if secure {
+			r.TLS = &tls.ConnectionState{}
+		}
+		return r
+	}
+	type args struct {
+		component string
+		r         *http.Request
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        string
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:        "@method",
+			args:        args{"@method", clientRequest(http.MethodPost, "https://www.example.com/path?param=value")},
+			want:        "POST",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@method not normalized",
+			args:        args{"@method", clientRequest("patch", "https://www.example.com/path?param=value")},
+			want:        "patch",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@target-uri",
+			args:        args{"@target-uri", clientRequest(http.MethodPost, "https://www.example.com/path?param=value")},
+			want:        "https://www.example.com/path?param=value",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@target-uri server side",
+			args:        args{"@target-uri", serverRequest("/path?param=value", "www.example.com:8443", true)},
+			want:        "https://www.example.com:8443/path?param=value",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@authority",
+			args:        args{"@authority", clientRequest(http.MethodPost, "https://WWW.Example.com/path?param=value")},
+			want:        "www.example.com",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@authority default port",
+			args:        args{"@authority", serverRequest("/", "www.example.com:80", false)},
+			want:        "www.example.com",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@authority non default port",
+			args:        args{"@authority", serverRequest("/", "www.example.com:80", true)},
+			want:        "www.example.com:80",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@authority IPv6",
+			args:        args{"@authority", clientRequest(http.MethodGet, "https://[2001:DB8::1]:443/")},
+			want:        "[2001:db8::1]",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@scheme",
+			args:        args{"@scheme", clientRequest(http.MethodPost, "https://www.example.com/path?param=value")},
+			want:        "https",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@scheme server side",
+			args:        args{"@scheme", serverRequest("/", "www.example.com", false)},
+			want:        "http",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@request-target",
+			args:        args{"@request-target", clientRequest(http.MethodPost, "https://www.example.com/path?param=value")},
+			want:        "/path?param=value",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@path",
+			args:        args{"@path", clientRequest(http.MethodPost, "https://www.example.com/path?param=value")},
+			want:        "/path",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@path empty",
+			args:        args{"@path", clientRequest(http.MethodPost, "https://www.example.com?param=value")},
+			want:        "/",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@query",
+			args:        args{"@query", clientRequest(http.MethodPost, "https://www.example.com/path?param=value&foo=bar&baz=bat%2Dman")},
+			want:        "?param=value&foo=bar&baz=bat%2Dman",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@query empty",
+			args:        args{"@query", clientRequest(http.MethodGet, "https://www.example.com/path")},
+			want:        "?",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@query-param",
+			args:        args{`@query-param;name="baz"`, clientRequest(http.MethodGet, "https://www.example.com/path?param=value&foo=bar&baz=batman&qux=")},
+			want:        "batman",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@query-param empty",
+			args:        args{`@query-param;name="qux"`, clientRequest(http.MethodGet, "https://www.example.com/path?param=value&foo=bar&baz=batman&qux=")},
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@query-param encoded spaces",
+			args:        args{`@query-param;name="var"`, clientRequest(http.MethodGet, "https://www.example.com/parameters?var=this%20is%20a%20big%0Amultiline%20value&bar=with+plus+whitespace&fa%C3%A7ade%22%3A%20=something")},
+			want:        "this%20is%20a%20big%0Amultiline%20value",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@query-param plus whitespace",
+			args:        args{`@query-param;name="bar"`, clientRequest(http.MethodGet, "https://www.example.com/parameters?var=this%20is%20a%20big%0Amultiline%20value&bar=with+plus+whitespace&fa%C3%A7ade%22%3A%20=something")},
+			want:        "with%20plus%20whitespace",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@query-param encoded name",
+			args:        args{`@query-param;name="fa%C3%A7ade%22%3A%20"`, clientRequest(http.MethodGet, "https://www.example.com/parameters?var=this%20is%20a%20big%0Amultiline%20value&bar=with+plus+whitespace&fa%C3%A7ade%22%3A%20=something")},
+			want:        "something",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "@query-param not found",
+			args:        args{`@query-param;name="pet"`, clientRequest(http.MethodGet, "https://www.example.com/path?param=value")},
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "query parameter 'pet', required in signature, not found",
+		},
+		{
+			name:        "@query-param repeated",
+			args:        args{`@query-param;name="pet"`, clientRequest(http.MethodGet, "https://www.example.com/path?pet=cat&pet=dog")},
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "query parameter 'pet' occurs multiple times",
+		},
+		{
+			name:        "@query-param without name",
+			args:        args{`@query-param`, clientRequest(http.MethodGet, "https://www.example.com/path?pet=cat")},
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "component '@query-param' requires 'name' parameter",
+		},
+		{
+			name:        "Unsupported parameter",
+			args:        args{`@path;name="x"`, clientRequest(http.MethodGet, "https://www.example.com/path")},
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "component parameter 'name' not supported",
+		},
+		{
+			name:        "@signature-params",
+			args:        args{`@signature-params`, clientRequest(http.MethodGet, "https://www.example.com/path")},
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "component '@signature-params' must not be covered",
+		},
+		{
+			name:        "Unknown component",
+			args:        args{`@unknown`, clientRequest(http.MethodGet, "https://www.example.com/path")},
+			want:        "",
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "derived component '@unknown' not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(NewSecretsStorage(map[string]Secret{}))
+			c, err := hs.parseComponent(tt.args.component)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := hs.componentValue(c, requestMessage(tt.args.r))
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestDerivedComponentsTrustedProxy(t *testing.T) {
+	hs := NewHTTPSignatures(NewSecretsStorage(map[string]Secret{}))
+	if err := hs.SetTrustedProxies([]string{"192.0.2.1"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/path?param=value", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("X-Forwarded-Host", "evil.example, www.example.com:443")
+	r.Header.Set("X-Forwarded-Proto", "http, HTTPS")
+
+	want := "https://www.example.com/path?param=value"
+	if got := hs.targetURI(r); got != want {
+		t.Errorf("got  = %s,\nwant = %s", got, want)
+	}
+}
+
+func TestVerifyMessageSignatureRFCExample(t *testing.T) {
+	// RFC 9421 B.2.5 Signing a Request Using hmac-sha256
+	r := getMessageSignatureRequestFunc()
+	r.Header.Set("Signature-Input", `sig-b25=("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`)
+	r.Header.Set("Signature", `sig-b25=:pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=:`)
+
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	if err := hs.VerifyMessageSignature(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestSignAndVerifyDerivedComponents(t *testing.T) {
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	secret, _ := messageSignaturesSecrets.Get("test-key-rsa")
+
+	var verifyErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifyErr = hs.VerifyMessageSignature(r)
+	}))
+	defer srv.Close()
+
+	r, _ := http.NewRequest(http.MethodGet, srv.URL+"/foo?param=Value&Pet=dog", nil)
+	err := hs.SignMessage(secret, r, MessageSignatureOptions{
+		Components: []string{
+			"@method", "@target-uri", "@authority", "@scheme", "@request-target",
+			"@path", "@query", `@query-param;name="Pet"`,
+		},
+		Created: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("sign error: %s", err)
+	}
+	resp, err := srv.Client().Do(r)
+	if err != nil {
+		t.Fatalf("request error: %s", err)
+	}
+	_ = resp.Body.Close()
+	if verifyErr != nil {
+		t.Errorf("verify error: %s", verifyErr)
+	}
+}
+
+func TestStructuredFieldComponentValue(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "https://www.example.com/", nil)
+	r.Header.Add("Example-Dict", " a=1,    b=2;x=1;y=2,   c=(a   b   c)")
+	r.Header.Add("Example-Dict", "d")
+	r.Header.Set("Example-Item", "  token ")
+	r.Header.Set("Example-List", "a,b")
+	tests := []struct {
+		name        string
+		component   string
+		want        string
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:        "Raw value",
+			component:   "example-dict",
+			want:        "a=1,    b=2;x=1;y=2,   c=(a   b   c), d",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Strict serialization",
+			component:   "example-dict;sf",
+			want:        "a=1, b=2;x=1;y=2, c=(a b c), d",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Dictionary member item",
+			component:   `example-dict;key="a"`,
+			want:        "1",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Dictionary member boolean",
+			component:   `example-dict;key="d"`,
+			want:        "?1",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Dictionary member with parameters",
+			component:   `example-dict;key="b"`,
+			want:        "2;x=1;y=2",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Dictionary member inner list",
+			component:   `example-dict;key="c"`,
+			want:        "(a b c)",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Dictionary member not found",
+			component:   `example-dict;key="e"`,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "key 'e' not found in header 'example-dict'",
+		},
+		{
+			name:        "Item",
+			component:   `example-item;sf`,
+			want:        "token",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "List",
+			component:   `example-list;sf`,
+			want:        "a, b",
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Key of a list",
+			component:   `example-list;key="a"`,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "header 'example-list' is not a dictionary",
+		},
+		{
+			name:        "Unknown structured field",
+			component:   `date;sf`,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "unknown structured field type of the header 'date'",
+		},
+		{
+			name:        "Unsupported parameter",
+			component:   `example-dict;bs`,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "component parameter 'bs' not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+			hs := NewHTTPSignatures(NewSecretsStorage(map[string]Secret{}))
+			hs.SetStructuredField("Example-Dict", sfv.DictionaryType)
+			hs.SetStructuredField("Example-Item", sfv.ItemType)
+			hs.SetStructuredField("Example-List", sfv.ListType)
+			c, err := hs.parseComponent(tt.component)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := hs.componentValue(c, requestMessage(r))
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
@@ -0,0 +1,563 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"httpsignatures/sfv"
+)
+
+const (
+	signatureInputHeader     = "Signature-Input"
+	defaultSignatureLabel    = "sig1"
+	signatureParamsComponent = "@signature-params"
+)
+
+// messageSignatureAlgorithms RFC 9421 algorithm names (HTTP Signature Algorithms registry)
+// mapped to signature hash algorithms
+var messageSignatureAlgorithms = map[string]string{
+	"rsa-v1_5-sha256": algoRsaSha256,
+	"hmac-sha256":     algoHmacSha256,
+}
+
+// MessageSignatureOptions RFC 9421 signature options
+// Label signature label (default: sig1)
+// Components covered components: lowercased header field names (`content-type`) or derived components.
+// Component parameters are separated by `;`, e.g. `@query-param;name="pet"`
+// Response signature could cover components of the request which triggered it with `req` parameter, e.g. `@path;req`
+// Created creation time (default: current time)
+// Expires expiration time (optional)
+// Nonce & Tag optional nonce & tag params
+// Alg add `alg` param with RFC 9421 algorithm name
+type MessageSignatureOptions struct {
+	Label      string
+	Components []string
+	Created    time.Time
+	Expires    time.Time
+	Nonce      string
+	Tag        string
+	Alg        bool
+}
+
+// MessageSignatureSelector select signatures to verify by labels and/or keyIDs.
+// Signature is selected if it matches both lists, empty list matches any signature
+type MessageSignatureSelector struct {
+	Labels []string
+	KeyIDs []string
+}
+
+// MessageSignatureResult result of verification of the labelled signature, Err is nil for valid signature
+type MessageSignatureResult struct {
+	VerificationResult
+	Err error
+}
+
+// SignMessage add RFC 9421 Signature-Input & Signature headers. New signature is appended to the existing ones,
+// label must be unique within the message
+func (hs *HTTPSignatures) SignMessage(s Secret, r *http.Request, o MessageSignatureOptions) error {
+	return hs.signMessage(s, requestMessage(r), o)
+}
+
+func (hs *HTTPSignatures) signMessage(s Secret, m message, o MessageSignatureOptions) error {
+	alg, _, ok := hs.algorithm(s.Algorithm)
+	if !ok {
+		return &Error{
+			fmt.Sprintf("algorithm '%s' not supported", s.Algorithm),
+			withKind(nil, ErrWrongAlgorithm),
+		}
+	}
+
+	input, err := hs.messageSignatureInput(s, o)
+	if err != nil {
+		return err
+	}
+	base, err := hs.buildSignatureBase(input, m)
+	if err != nil {
+		return &Error{"build signature base error", err}
+	}
+	sig, err := alg.Create(s, base)
+	if err != nil {
+		return &Error{"error creating signature", err}
+	}
+
+	label := o.Label
+	if len(label) == 0 {
+		label = defaultSignatureLabel
+	}
+	sigInput, err := sfv.SerializeDictionary(sfv.Dictionary{{Key: label, Value: input}})
+	if err != nil {
+		return &Error{"error serializing signature input", err}
+	}
+	sigValue, err := sfv.SerializeDictionary(sfv.Dictionary{{Key: label, Value: sfv.Item{Value: sig}}})
+	if err != nil {
+		return &Error{"error serializing signature", err}
+	}
+
+	for _, name := range []string{signatureInputHeader, signatureHeader} {
+		d, err := hs.parseDictionaryHeader(m.header, name)
+		if err != nil {
+			return err
+		}
+		if _, ok := d.Get(label); ok {
+			return &Error{fmt.Sprintf("signature label '%s' already exists", label), nil}
+		}
+	}
+	hs.appendDictionaryMember(m.header, signatureInputHeader, sigInput)
+	hs.appendDictionaryMember(m.header, signatureHeader, sigValue)
+
+	return nil
+}
+
+// MessageSignatureLabels labels of all RFC 9421 signatures of the request
+func (hs *HTTPSignatures) MessageSignatureLabels(r *http.Request) ([]string, error) {
+	inputs, err := hs.parseDictionaryHeader(r.Header, signatureInputHeader)
+	if err != nil {
+		return nil, err
+	}
+	return inputs.Keys(), nil
+}
+
+// VerifyMessageSignature verify all RFC 9421 signatures of the request
+func (hs *HTTPSignatures) VerifyMessageSignature(r *http.Request) error {
+	_, err := hs.verifyMessageSignatures(requestMessage(r), MessageSignatureSelector{})
+	return err
+}
+
+// VerifyMessageSignatures verify RFC 9421 signatures of the request selected by labels and/or keyIDs.
+// Results are reported for every selected signature, error is returned if any of them is not valid
+func (hs *HTTPSignatures) VerifyMessageSignatures(
+	r *http.Request, sel MessageSignatureSelector,
+) ([]MessageSignatureResult, error) {
+	return hs.verifyMessageSignatures(requestMessage(r), sel)
+}
+
+// VerifyMessageResponse verify all RFC 9421 signatures of the response
+func (hs *HTTPSignatures) VerifyMessageResponse(resp *http.Response) error {
+	_, err := hs.verifyMessageSignatures(responseMessage(resp), MessageSignatureSelector{})
+	return err
+}
+
+// VerifyMessageResponseForRequest verify all RFC 9421 signatures of the response bound to the request which
+// triggered it: components with `req` parameter are taken from the request r
+func (hs *HTTPSignatures) VerifyMessageResponseForRequest(r *http.Request, resp *http.Response) error {
+	m := responseMessage(resp)
+	m.request = r
+	_, err := hs.verifyMessageSignatures(m, MessageSignatureSelector{})
+	return err
+}
+
+func (hs *HTTPSignatures) verifyMessageSignatures(
+	msg message, sel MessageSignatureSelector,
+) ([]MessageSignatureResult, error) {
+	inputs, err := hs.parseDictionaryHeader(msg.header, signatureInputHeader)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		return nil, &Error{"signature-input header not found", withKind(nil, ErrMissingHeader)}
+	}
+	sigs, err := hs.parseDictionaryHeader(msg.header, signatureHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]MessageSignatureResult, 0, len(inputs))
+	for _, m := range inputs {
+		var keyID string
+		if input, ok := m.Value.(sfv.InnerList); ok {
+			keyID, _ = hs.stringParam(input.Params, "keyid")
+		}
+		if !sel.matches(m.Key, keyID) {
+			continue
+		}
+		res, err := hs.verifyMessageSignature(m.Key, m.Value, sigs, msg)
+		results = append(results, MessageSignatureResult{res, err})
+	}
+	if len(results) == 0 {
+		return results, &Error{"no signatures match the selector", nil}
+	}
+	for _, res := range results {
+		if res.Err != nil {
+			return results, res.Err
+		}
+	}
+	return results, nil
+}
+
+func (sel MessageSignatureSelector) matches(label string, keyID string) bool {
+	return sel.contains(sel.Labels, label) && sel.contains(sel.KeyIDs, keyID)
+}
+
+func (sel MessageSignatureSelector) contains(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (hs *HTTPSignatures) verifyMessageSignature(
+	label string, member sfv.Member, sigs sfv.Dictionary, m message,
+) (VerificationResult, error) {
+	res := VerificationResult{Format: MessageSignatureFormat, Label: label}
+	input, ok := member.(sfv.InnerList)
+	if !ok {
+		return res, &Error{
+			fmt.Sprintf("signature input '%s' must be an inner list", label),
+			withKind(nil, ErrMalformedHeader),
+		}
+	}
+	sm, ok := sigs.Get(label)
+	if !ok {
+		return res, &Error{fmt.Sprintf("signature '%s' not found", label), withKind(nil, ErrMissingHeader)}
+	}
+	sigItem, _ := sm.(sfv.Item)
+	sig, ok := sigItem.Value.([]byte)
+	if !ok {
+		return res, &Error{
+			fmt.Sprintf("signature '%s' must be a byte sequence", label),
+			withKind(nil, ErrMalformedHeader),
+		}
+	}
+
+	res.Components = make([]string, 0, len(input.Items))
+	for _, c := range input.Items {
+		if name, ok := c.Value.(string); ok {
+			params, _ := sfv.SerializeParams(c.Params)
+			res.Components = append(res.Components, name+params)
+		}
+	}
+	if v, ok := input.Params.Get("created"); ok {
+		if c, ok := v.(int64); ok {
+			res.Created = time.Unix(c, 0)
+		}
+	}
+	if v, ok := input.Params.Get("expires"); ok {
+		if e, ok := v.(int64); ok {
+			res.Expires = time.Unix(e, 0)
+		}
+	}
+	res.Params = hs.extensionParams(input.Params)
+
+	keyID, ok := hs.stringParam(input.Params, "keyid")
+	if !ok {
+		return res, &Error{
+			fmt.Sprintf("keyid is not set in signature input '%s'", label),
+			withKind(nil, ErrMalformedHeader),
+		}
+	}
+	res.KeyID = keyID
+	secret, err := hs.ss.Get(keyID)
+	if err != nil {
+		return res, &Error{fmt.Sprintf("keyID '%s' not found", keyID), withKind(err, ErrKeyNotFound)}
+	}
+	if name, ok := hs.stringParam(input.Params, "alg"); ok {
+		a, ok := messageSignatureAlgorithms[name]
+		if !ok || !strings.EqualFold(secret.Algorithm, a) {
+			return res, &Error{
+				fmt.Sprintf("wrong algorithm '%s' for keyID '%s'", name, keyID),
+				withKind(nil, ErrWrongAlgorithm),
+			}
+		}
+	}
+	res.Algorithm = secret.Algorithm
+	res.Metadata = secret.Metadata
+	alg, policy, ok := hs.algorithm(secret.Algorithm)
+	if !ok {
+		return res, &Error{
+			fmt.Sprintf("algorithm '%s' not supported", secret.Algorithm),
+			withKind(nil, ErrWrongAlgorithm),
+		}
+	}
+	if err = hs.verifyPolicy(policy, secret, alg); err != nil {
+		return res, err
+	}
+	if err = hs.verifyRequiredComponents(policy, label, input); err != nil {
+		return res, err
+	}
+	if err = hs.verifyRequiredParams(policy, res.Params); err != nil {
+		return res, err
+	}
+
+	if v, ok := input.Params.Get("expires"); ok {
+		e, ok := v.(int64)
+		if !ok {
+			return res, &Error{
+				fmt.Sprintf("wrong 'expires' param in signature input '%s'", label),
+				withKind(nil, ErrMalformedHeader),
+			}
+		}
+		if time.Unix(e, 0).Before(timeNow()) {
+			return res, &Error{fmt.Sprintf("signature '%s' expired", label), withKind(nil, ErrExpired)}
+		}
+	}
+
+	components := make([]string, 0, len(input.Items))
+	for _, c := range input.Items {
+		if _, ok := c.Params.Get("req"); ok {
+			continue
+		}
+		if name, ok := c.Value.(string); ok {
+			components = append(components, name)
+		}
+	}
+	if err = hs.verifyDigest(components, m); err != nil {
+		return res, err
+	}
+
+	base, err := hs.buildSignatureBase(input, m)
+	if err != nil {
+		return res, &Error{"build signature base error", err}
+	}
+	res.SignatureString = hs.debugSignatureString(base)
+	if err = alg.Verify(secret, base, sig); err != nil {
+		if len(res.SignatureString) > 0 {
+			return res, &Error{
+				fmt.Sprintf("wrong signature, signature base %q", res.SignatureString),
+				withKind(err, ErrInvalidSignature),
+			}
+		}
+		return res, &Error{"wrong signature", withKind(err, ErrInvalidSignature)}
+	}
+
+	return res, nil
+}
+
+// verifyRequiredComponents check all components required by the policy are covered by the signature
+func (hs *HTTPSignatures) verifyRequiredComponents(p Policy, label string, input sfv.InnerList) error {
+	if len(p.RequiredComponents) == 0 {
+		return nil
+	}
+	covered := make(map[string]bool, len(input.Items))
+	for _, c := range input.Items {
+		if id, err := sfv.SerializeItem(c); err == nil {
+			covered[id] = true
+		}
+	}
+	for _, rc := range p.RequiredComponents {
+		c, err := hs.parseComponent(rc)
+		if err != nil {
+			return err
+		}
+		id, err := sfv.SerializeItem(c)
+		if err != nil {
+			return &Error{"wrong component identifier", err}
+		}
+		if !covered[id] {
+			return &Error{
+				fmt.Sprintf("component %s required by policy is not covered by signature '%s'", id, label),
+				withKind(nil, ErrPolicyViolation),
+			}
+		}
+	}
+	return nil
+}
+
+// extensionParams signature params except created, expires, keyid & alg which are verified by the library
+func (hs *HTTPSignatures) extensionParams(params sfv.Params) map[string]string {
+	ext := make(map[string]string, len(params))
+	for _, p := range params {
+		switch p.Key {
+		case "created", "expires", "keyid", "alg":
+			continue
+		}
+		if s, ok := p.Value.(string); ok {
+			ext[p.Key] = s
+			continue
+		}
+		if s, err := sfv.SerializeItem(sfv.Item{Value: p.Value}); err == nil {
+			ext[p.Key] = s
+		}
+	}
+	return ext
+}
+
+// messageSignatureInput create signature input: covered components & signature params
+func (hs *HTTPSignatures) messageSignatureInput(s Secret, o MessageSignatureOptions) (sfv.InnerList, error) {
+	input := sfv.InnerList{Items: make([]sfv.Item, 0, len(o.Components))}
+	for _, c := range o.Components {
+		item, err := hs.parseComponent(c)
+		if err != nil {
+			return sfv.InnerList{}, err
+		}
+		input.Items = append(input.Items, item)
+	}
+
+	created := o.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	input.Params.Set("created", created.Unix())
+	if !o.Expires.IsZero() {
+		input.Params.Set("expires", o.Expires.Unix())
+	}
+	if len(o.Nonce) > 0 {
+		input.Params.Set("nonce", o.Nonce)
+	}
+	if o.Alg {
+		name, ok := hs.messageAlgorithmName(s.Algorithm)
+		if !ok {
+			return sfv.InnerList{}, &Error{
+				fmt.Sprintf("algorithm '%s' has no RFC 9421 name", s.Algorithm),
+				nil,
+			}
+		}
+		input.Params.Set("alg", name)
+	}
+	input.Params.Set("keyid", s.KeyID)
+	if len(o.Tag) > 0 {
+		input.Params.Set("tag", o.Tag)
+	}
+
+	return input, nil
+}
+
+// parseComponent parse component identifier: `content-type`, `@query-param;name="pet"` or `"content-type"`
+func (hs *HTTPSignatures) parseComponent(c string) (sfv.Item, error) {
+	if !strings.HasPrefix(c, `"`) {
+		i := strings.IndexByte(c, ';')
+		if i == -1 {
+			i = len(c)
+		}
+		c = `"` + c[:i] + `"` + c[i:]
+	}
+	item, err := sfv.ParseItem(c)
+	if err != nil {
+		return sfv.Item{}, &Error{fmt.Sprintf("wrong component identifier %s", c), err}
+	}
+	if _, ok := item.Value.(string); !ok {
+		return sfv.Item{}, &Error{fmt.Sprintf("wrong component identifier %s", c), nil}
+	}
+	return item, nil
+}
+
+// buildSignatureBase create signature base (2.5)
+func (hs *HTTPSignatures) buildSignatureBase(input sfv.InnerList, m message) ([]byte, error) {
+	var b bytes.Buffer
+	seen := make(map[string]bool, len(input.Items))
+	for _, c := range input.Items {
+		id, err := sfv.SerializeItem(c)
+		if err != nil {
+			return nil, &Error{"wrong component identifier", err}
+		}
+		if seen[id] {
+			return nil, &Error{fmt.Sprintf("duplicate component %s", id), nil}
+		}
+		seen[id] = true
+
+		v, err := hs.componentValue(c, m)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(fmt.Sprintf("%s: %s\n", id, v))
+	}
+
+	params, err := sfv.SerializeInnerList(input)
+	if err != nil {
+		return nil, &Error{"wrong signature params", err}
+	}
+	b.WriteString(fmt.Sprintf("\"%s\": %s", signatureParamsComponent, params))
+
+	return b.Bytes(), nil
+}
+
+// componentValue value of the covered component (2.1, 2.2)
+func (hs *HTTPSignatures) componentValue(c sfv.Item, m message) (string, error) {
+	name, ok := c.Value.(string)
+	if !ok {
+		return "", &Error{"component identifier must be a string", nil}
+	}
+	if name != strings.ToLower(name) {
+		return "", &Error{fmt.Sprintf("component name '%s' must be lowercased", name), nil}
+	}
+	if v, ok := c.Params.Get("req"); ok {
+		return hs.requestComponentValue(name, v, c.Params, m)
+	}
+	if strings.HasPrefix(name, "@") {
+		return hs.derivedComponentValue(name, c.Params, m)
+	}
+	if name == hostHeader && len(c.Params) == 0 && !m.isResponse() {
+		if host := hs.host(m.request); len(host) > 0 {
+			return host, nil
+		}
+	}
+	values, ok := m.header[textproto.CanonicalMIMEHeaderKey(name)]
+	if !ok {
+		return "", &Error{
+			fmt.Sprintf("header '%s', required in signature, not found", name),
+			withKind(nil, ErrMissingHeader),
+		}
+	}
+	if len(c.Params) > 0 {
+		return hs.structuredFieldValue(name, c.Params, values)
+	}
+	return hs.headerValue(values), nil
+}
+
+// requestComponentValue value of the request component covered by the response signature (2.4)
+func (hs *HTTPSignatures) requestComponentValue(
+	name string, req interface{}, params sfv.Params, m message,
+) (string, error) {
+	if req != true {
+		return "", &Error{"component parameter 'req' must be boolean true", nil}
+	}
+	if !m.isResponse() {
+		return "", &Error{fmt.Sprintf("component '%s' with 'req' parameter is available for response only", name), nil}
+	}
+	if m.request == nil {
+		return "", &Error{fmt.Sprintf("request for component '%s' with 'req' parameter not set", name), nil}
+	}
+
+	c := sfv.Item{Value: name, Params: make(sfv.Params, 0, len(params))}
+	for _, p := range params {
+		if p.Key != "req" {
+			c.Params = append(c.Params, p)
+		}
+	}
+	return hs.componentValue(c, requestMessage(m.request))
+}
+
+// appendDictionaryMember append serialized dictionary member to the header keeping existing members as is
+func (hs *HTTPSignatures) appendDictionaryMember(h http.Header, name string, member string) {
+	values := h[textproto.CanonicalMIMEHeaderKey(name)]
+	if len(values) == 0 {
+		h.Set(name, member)
+		return
+	}
+	h.Set(name, strings.Join(values, ", ")+", "+member)
+}
+
+// parseDictionaryHeader parse dictionary from all values of the header combined into one (RFC 8941 4.2)
+func (hs *HTTPSignatures) parseDictionaryHeader(h http.Header, name string) (sfv.Dictionary, error) {
+	d, err := sfv.ParseDictionary(strings.Join(h[textproto.CanonicalMIMEHeaderKey(name)], ", "))
+	if err != nil {
+		return nil, &Error{fmt.Sprintf("wrong %s header", name), withKind(err, ErrMalformedHeader)}
+	}
+	return d, nil
+}
+
+func (hs *HTTPSignatures) stringParam(p sfv.Params, name string) (string, bool) {
+	v, ok := p.Get(name)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+func (hs *HTTPSignatures) messageAlgorithmName(algorithm string) (string, bool) {
+	for name, a := range messageSignatureAlgorithms {
+		if strings.EqualFold(a, algorithm) {
+			return name, true
+		}
+	}
+	return "", false
+}
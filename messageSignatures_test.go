@@ -0,0 +1,570 @@
+package httpsignatures
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const messageSignaturesHostExample = "https://example.com/foo?param=Value&Pet=dog"
+const messageSignaturesHmacKey = "uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ=="
+
+var messageSignaturesSecrets = (func() *SecretsStorage {
+	key, _ := base64.StdEncoding.DecodeString(messageSignaturesHmacKey)
+	return NewSecretsStorage(map[string]Secret{
+		"test-shared-secret": {
+			KeyID:      "test-shared-secret",
+			PrivateKey: string(key),
+			Algorithm:  "HMAC-SHA256",
+		},
+		"test-key-rsa": {
+			KeyID:      "test-key-rsa",
+			PrivateKey: rsaPrivateKey,
+			PublicKey:  rsaPublicKey,
+			Algorithm:  "RSA-SHA256",
+		},
+	})
+})()
+
+var getMessageSignatureRequestFunc = func() *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, messageSignaturesHostExample, strings.NewReader(httpsignaturesBodyExample))
+	r.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Length", "18")
+	return r
+}
+
+func TestBuildSignatureBase(t *testing.T) {
+	type args struct {
+		components []string
+		o          MessageSignatureOptions
+		r          *http.Request
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        []byte
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Header fields",
+			args: args{
+				o: MessageSignatureOptions{
+					Components: []string{"date", "content-type", "host"},
+					Created:    time.Unix(1618884473, 0),
+					Expires:    time.Unix(1618884773, 0),
+					Nonce:      "b3k2pp5k7z-50gnwp.yemd",
+					Tag:        "app-123",
+				},
+				r: getMessageSignatureRequestFunc(),
+			},
+			want: []byte(`"date": Tue, 20 Apr 2021 02:07:55 GMT` + "\n" +
+				`"content-type": application/json` + "\n" +
+				`"host": example.com` + "\n" +
+				`"@signature-params": ("date" "content-type" "host");created=1618884473;expires=1618884773;` +
+				`nonce="b3k2pp5k7z-50gnwp.yemd";keyid="test-shared-secret";tag="app-123"`),
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "No components",
+			args: args{
+				o: MessageSignatureOptions{
+					Created: time.Unix(1618884473, 0),
+					Alg:     true,
+				},
+				r: getMessageSignatureRequestFunc(),
+			},
+			want:        []byte(`"@signature-params": ();created=1618884473;alg="hmac-sha256";keyid="test-shared-secret"`),
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Duplicate component",
+			args: args{
+				o: MessageSignatureOptions{
+					Components: []string{"date", "date"},
+				},
+				r: getMessageSignatureRequestFunc(),
+			},
+			want:        nil,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  `duplicate component "date"`,
+		},
+		{
+			name: "Header not found",
+			args: args{
+				o: MessageSignatureOptions{
+					Components: []string{"digest"},
+				},
+				r: getMessageSignatureRequestFunc(),
+			},
+			want:        nil,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "header 'digest', required in signature, not found",
+		},
+		{
+			name: "Uppercase component",
+			args: args{
+				o: MessageSignatureOptions{
+					Components: []string{"Date"},
+				},
+				r: getMessageSignatureRequestFunc(),
+			},
+			want:        nil,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "component name 'Date' must be lowercased",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			secret, _ := messageSignaturesSecrets.Get("test-shared-secret")
+			input, err := hs.messageSignatureInput(secret, tt.args.o)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := hs.buildSignatureBase(input, requestMessage(tt.args.r))
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	secret, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	r := getMessageSignatureRequestFunc()
+	err := hs.SignMessage(secret, r, MessageSignatureOptions{
+		Components: []string{"date", "content-type"},
+		Created:    time.Unix(1618884473, 0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	wantInput := `sig1=("date" "content-type");created=1618884473;keyid="test-shared-secret"`
+	if got := r.Header.Get("Signature-Input"); got != wantInput {
+		t.Errorf("signature input\ngot  = %s,\nwant = %s", got, wantInput)
+	}
+	wantSignature := `sig1=:tvqgXRD5g3mzNmVnYBuxtg5V8PYDi+c9QloHuKjuQQg=:`
+	if got := r.Header.Get("Signature"); got != wantSignature {
+		t.Errorf("signature\ngot  = %s,\nwant = %s", got, wantSignature)
+	}
+}
+
+func TestSignAndVerifyMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		keyID string
+		o     MessageSignatureOptions
+	}{
+		{
+			name:  "HMAC-SHA256",
+			keyID: "test-shared-secret",
+			o: MessageSignatureOptions{
+				Label:      "hmac",
+				Components: []string{"date", "content-type", "content-length", "host"},
+				Alg:        true,
+			},
+		},
+		{
+			name:  "RSA-SHA256",
+			keyID: "test-key-rsa",
+			o: MessageSignatureOptions{
+				Components: []string{"date", "content-type"},
+				Expires:    time.Now().Add(time.Minute),
+				Nonce:      "nonce",
+				Tag:        "tag",
+				Alg:        true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			secret, _ := messageSignaturesSecrets.Get(tt.keyID)
+			r := getMessageSignatureRequestFunc()
+			if err := hs.SignMessage(secret, r, tt.o); err != nil {
+				t.Fatalf("sign error: %s", err)
+			}
+			if err := hs.VerifyMessageSignature(r); err != nil {
+				t.Errorf("verify error: %s", err)
+			}
+		})
+	}
+}
+
+func TestVerifyMessageSignature(t *testing.T) {
+	const validInput = `sig1=("date" "content-type");created=1618884473;keyid="test-shared-secret"`
+	const validSignature = `sig1=:tvqgXRD5g3mzNmVnYBuxtg5V8PYDi+c9QloHuKjuQQg=:`
+	type args struct {
+		input     string
+		signature string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Valid signature",
+			args: args{
+				input:     validInput,
+				signature: validSignature,
+			},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "No Signature-Input header",
+			args: args{
+				signature: validSignature,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "signature-input header not found",
+		},
+		{
+			name: "Wrong Signature-Input header",
+			args: args{
+				input:     `sig1=("date"`,
+				signature: validSignature,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong Signature-Input header: StructuredFieldError: unexpected end of inner list at position 12",
+		},
+		{
+			name: "Signature-Input is not an inner list",
+			args: args{
+				input:     `sig1="date"`,
+				signature: validSignature,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "signature input 'sig1' must be an inner list",
+		},
+		{
+			name: "Signature not found",
+			args: args{
+				input:     validInput,
+				signature: `sig2=:tvqgXRD5g3mzNmVnYBuxtg5V8PYDi+c9QloHuKjuQQg=:`,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "signature 'sig1' not found",
+		},
+		{
+			name: "Signature is not a byte sequence",
+			args: args{
+				input:     validInput,
+				signature: `sig1="tvqgXRD5g3mzNmVnYBuxtg5V8PYDi+c9QloHuKjuQQg="`,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "signature 'sig1' must be a byte sequence",
+		},
+		{
+			name: "No keyid",
+			args: args{
+				input:     `sig1=("date" "content-type");created=1618884473`,
+				signature: validSignature,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "keyid is not set in signature input 'sig1'",
+		},
+		{
+			name: "Unknown keyid",
+			args: args{
+				input:     `sig1=("date" "content-type");created=1618884473;keyid="unknown"`,
+				signature: validSignature,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "keyID 'unknown' not found: SecretError: secret not found",
+		},
+		{
+			name: "Wrong alg",
+			args: args{
+				input:     `sig1=("date" "content-type");created=1618884473;keyid="test-shared-secret";alg="rsa-v1_5-sha256"`,
+				signature: validSignature,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong algorithm 'rsa-v1_5-sha256' for keyID 'test-shared-secret'",
+		},
+		{
+			name: "Expired signature",
+			args: args{
+				input:     `sig1=("date" "content-type");created=1618884473;expires=1618884773;keyid="test-shared-secret"`,
+				signature: validSignature,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "signature 'sig1' expired",
+		},
+		{
+			name: "Wrong signature",
+			args: args{
+				input:     `sig1=("date");created=1618884473;keyid="test-shared-secret"`,
+				signature: validSignature,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong signature: CryptoError: wrong signature",
+		},
+		{
+			name: "Unsupported component parameter",
+			args: args{
+				input:     `sig1=("date";bs);created=1618884473;keyid="test-shared-secret"`,
+				signature: validSignature,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "build signature base error: component parameter 'bs' not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			r := getMessageSignatureRequestFunc()
+			if len(tt.args.input) > 0 {
+				r.Header.Set("Signature-Input", tt.args.input)
+			}
+			r.Header.Set("Signature", tt.args.signature)
+			err := hs.VerifyMessageSignature(r)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestVerifyMessageResponseForRequest(t *testing.T) {
+	secret, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	type args struct {
+		components []string
+		r          func(r *http.Request) *http.Request
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Request components",
+			args: args{
+				components: []string{"@status", "@method;req", "@path;req", `signature;req;key="sig1"`},
+				r: func(r *http.Request) *http.Request {
+					return r
+				},
+			},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Request header",
+			args: args{
+				components: []string{"@status", "date;req", "content-type;req"},
+				r: func(r *http.Request) *http.Request {
+					return r
+				},
+			},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Another request",
+			args: args{
+				components: []string{"@status", "@method;req", "@path;req", `signature;req;key="sig1"`},
+				r: func(r *http.Request) *http.Request {
+					r2 := r.Clone(r.Context())
+					r2.URL.Path = "/bar"
+					return r2
+				},
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong signature: CryptoError: wrong signature",
+		},
+		{
+			name: "Request not set",
+			args: args{
+				components: []string{"@status", "@method;req"},
+				r: func(r *http.Request) *http.Request {
+					return nil
+				},
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "build signature base error: request for component '@method' with 'req' parameter not set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sw := hs.SignMessageResponse(secret, w, r, MessageSignatureOptions{Components: tt.args.components})
+				if err := sw.Close(); err != nil {
+					t.Errorf("sign error: %s", err)
+				}
+			}))
+			defer srv.Close()
+
+			r := getMessageSignatureRequestFunc()
+			r.URL.Host = strings.TrimPrefix(srv.URL, "http://")
+			r.URL.Scheme = "http"
+			r.Host = ""
+			if err := hs.SignMessage(secret, r, MessageSignatureOptions{Components: []string{"@method", "@path"}}); err != nil {
+				t.Fatalf("sign error: %s", err)
+			}
+			resp, err := srv.Client().Do(r)
+			if err != nil {
+				t.Fatalf("request error: %s", err)
+			}
+			_ = resp.Body.Close()
+
+			err = hs.VerifyMessageResponseForRequest(tt.args.r(r), resp)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestRequestComponentInRequest(t *testing.T) {
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	c, _ := hs.parseComponent("@method;req")
+	_, err := hs.componentValue(c, requestMessage(getMessageSignatureRequestFunc()))
+	assert(t, nil, err, httpsignaturesErrType, "@method;req", nil,
+		"component '@method' with 'req' parameter is available for response only")
+}
+
+func TestSignMessageMultipleLabels(t *testing.T) {
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	client, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	gateway, _ := messageSignaturesSecrets.Get("test-key-rsa")
+	r := getMessageSignatureRequestFunc()
+	err := hs.SignMessage(client, r, MessageSignatureOptions{
+		Components: []string{"date", "content-type"},
+		Created:    time.Unix(1618884473, 0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = hs.SignMessage(gateway, r, MessageSignatureOptions{
+		Label:      "proxy",
+		Components: []string{`signature;key="sig1"`, "@authority"},
+		Created:    time.Unix(1618884480, 0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	wantInput := `sig1=("date" "content-type");created=1618884473;keyid="test-shared-secret", ` +
+		`proxy=("signature";key="sig1" "@authority");created=1618884480;keyid="test-key-rsa"`
+	if got := r.Header.Get("Signature-Input"); got != wantInput {
+		t.Errorf("signature input\ngot  = %s,\nwant = %s", got, wantInput)
+	}
+	wantSignature := `sig1=:tvqgXRD5g3mzNmVnYBuxtg5V8PYDi+c9QloHuKjuQQg=:, proxy=:`
+	if got := r.Header.Get("Signature"); !strings.HasPrefix(got, wantSignature) {
+		t.Errorf("signature\ngot  = %s,\nwant prefix = %s", got, wantSignature)
+	}
+	labels, err := hs.MessageSignatureLabels(r)
+	assert(t, labels, err, httpsignaturesErrType, "labels", []string{"sig1", "proxy"}, "")
+
+	err = hs.SignMessage(client, r, MessageSignatureOptions{Label: "proxy"})
+	assert(t, nil, err, httpsignaturesErrType, "duplicate label", nil, "signature label 'proxy' already exists")
+}
+
+func TestVerifyMessageSignatures(t *testing.T) {
+	type args struct {
+		sel  MessageSignatureSelector
+		date string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        map[string]string
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:        "All signatures",
+			args:        args{},
+			want:        map[string]string{"sig1": "", "proxy": ""},
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "By label",
+			args: args{
+				sel: MessageSignatureSelector{Labels: []string{"proxy"}},
+			},
+			want:        map[string]string{"proxy": ""},
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "By keyID",
+			args: args{
+				sel: MessageSignatureSelector{KeyIDs: []string{"test-shared-secret"}},
+			},
+			want:        map[string]string{"sig1": ""},
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "No signatures selected",
+			args: args{
+				sel: MessageSignatureSelector{Labels: []string{"sig1"}, KeyIDs: []string{"test-key-rsa"}},
+			},
+			want:        map[string]string{},
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "no signatures match the selector",
+		},
+		{
+			name: "One signature is not valid",
+			args: args{
+				date: "Tue, 20 Apr 2021 02:07:56 GMT",
+			},
+			want:        map[string]string{"sig1": "wrong signature: CryptoError: wrong signature", "proxy": ""},
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong signature: CryptoError: wrong signature",
+		},
+		{
+			name: "Valid signature selected",
+			args: args{
+				sel:  MessageSignatureSelector{Labels: []string{"proxy"}},
+				date: "Tue, 20 Apr 2021 02:07:56 GMT",
+			},
+			want:        map[string]string{"proxy": ""},
+			wantErrType: httpsignaturesErrType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			client, _ := messageSignaturesSecrets.Get("test-shared-secret")
+			gateway, _ := messageSignaturesSecrets.Get("test-key-rsa")
+			r := getMessageSignatureRequestFunc()
+			_ = hs.SignMessage(client, r, MessageSignatureOptions{Components: []string{"date", "content-type"}})
+			_ = hs.SignMessage(gateway, r, MessageSignatureOptions{Label: "proxy", Components: []string{`signature;key="sig1"`}})
+			if len(tt.args.date) > 0 {
+				r.Header.Set("Date", tt.args.date)
+			}
+
+			results, err := hs.VerifyMessageSignatures(r, tt.args.sel)
+			got := make(map[string]string, len(results))
+			for _, res := range results {
+				got[res.Label] = ""
+				if res.Err != nil {
+					got[res.Label] = res.Err.Error()
+				}
+			}
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
@@ -2,9 +2,9 @@ package httpsignatures
 
 import (
 	"fmt"
-	"io"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,8 +21,35 @@ const (
 	from0 byte = '0'
 	to9   byte = '9'
 	min   byte = '-'
+	dot   byte = '.'
+	tab   byte = '\t'
+	bsl   byte = '\\'
+	del   byte = 0x7f
 )
 
+// Indexes of the params defined by the draft
+const (
+	paramKeyID = iota
+	paramAlgorithm
+	paramCreated
+	paramExpires
+	paramHeaders
+	paramSignature
+)
+
+// knownParams params defined by the draft, matched case-insensitively by the lenient parser
+var knownParams = [...]string{
+	paramKeyID:     "keyId",
+	paramAlgorithm: "algorithm",
+	paramCreated:   "created",
+	paramExpires:   "expires",
+	paramHeaders:   "headers",
+	paramSignature: "signature",
+}
+
+// maxTimestamp max Unix time of created & expires params: 9999-12-31T23:59:59Z
+const maxTimestamp = 253402300799
+
 // ParsedHeader Authorization or Signature header parsed into params
 type ParsedHeader struct {
 	keyword   string
@@ -30,8 +57,53 @@ type ParsedHeader struct {
 	signature string    // REQUIRED
 	algorithm string    // RECOMMENDED
 	created   time.Time // RECOMMENDED
-	expires   time.Time // OPTIONAL (Not implemented: "Subsecond precision is allowed using decimal notation.")
+	expires   time.Time // OPTIONAL (Subsecond precision is allowed using decimal notation)
 	headers   []string  // OPTIONAL
+	params    map[string]string
+}
+
+// Keyword keyword of the Authorization header (`Signature`), empty for Signature header
+func (ph ParsedHeader) Keyword() string {
+	return ph.keyword
+}
+
+// KeyID keyId param
+func (ph ParsedHeader) KeyID() string {
+	return ph.keyID
+}
+
+// Signature base64 encoded signature param
+func (ph ParsedHeader) Signature() string {
+	return ph.signature
+}
+
+// Algorithm algorithm param
+func (ph ParsedHeader) Algorithm() string {
+	return ph.algorithm
+}
+
+// Created created param, zero time if not set
+func (ph ParsedHeader) Created() time.Time {
+	return ph.created
+}
+
+// Expires expires param, zero time if not set
+func (ph ParsedHeader) Expires() time.Time {
+	return ph.expires
+}
+
+// Headers list of signed headers, `(created)` if headers param is not set
+func (ph ParsedHeader) Headers() []string {
+	return append([]string(nil), ph.headers...)
+}
+
+// Params unrecognised params: ignored during verification, but available for logging, policy or routing
+func (ph ParsedHeader) Params() map[string]string {
+	params := make(map[string]string, len(ph.params))
+	for k, v := range ph.params {
+		params[k] = v
+	}
+	return params
 }
 
 // ParsedDigestHeader Digest header parsed into params (alg & digest)
@@ -40,6 +112,16 @@ type ParsedDigestHeader struct {
 	digest string
 }
 
+// Algorithm uppercased digest hash algorithm
+func (pd ParsedDigestHeader) Algorithm() string {
+	return pd.algo
+}
+
+// Digest base64 encoded digest
+func (pd ParsedDigestHeader) Digest() string {
+	return pd.digest
+}
+
 // ParserError errors during parsing
 type ParserError struct {
 	Message string
@@ -57,39 +139,113 @@ func (e *ParserError) Error() string {
 	return fmt.Sprintf("ParserError: %s", e.Message)
 }
 
+// Unwrap wrapped error
+func (e *ParserError) Unwrap() error {
+	return e.Err
+}
+
+// Is parser errors are errors of malformed header
+func (e *ParserError) Is(target error) bool {
+	return target == ErrMalformedHeader
+}
+
+// parserState stage of the parser
+type parserState int
+
+// Parser stages
+const (
+	stateNone parserState = iota
+	stateKeyword
+	stateParam
+	stateEqual
+	stateQuote
+	stateStringValue
+	stateQuotedPair
+	stateDecimalValue
+	stateQuotedDecimalValue
+	stateDiv
+	stateAlgorithm
+	stateRawValue
+)
+
+// parserPool parsers reused between verifications
+var parserPool = sync.Pool{
+	New: func() interface{} {
+		return NewParser()
+	},
+}
+
 // Parser parser internal struct
+// Header is parsed in a single pass: key & value are kept as offsets in the header, so parsed params are substrings
+// of the header & no memory is allocated for them (except values with escaped symbols).
+// Parser could be reused after Reset.
+// Lenient parser (default) is tolerant to common deviations for interop: known param names are matched
+// case-insensitively, created & expires values could be quoted.
+// Strict parser rejects anything not conforming to the draft ABNF: control characters & wrong escapes
+// in quoted strings, quoted or non-integer created values.
 type Parser struct {
 	parsedHeader       ParsedHeader
 	parsedDigestHeader ParsedDigestHeader
-	keyword            []byte
-	key                []byte
-	value              []byte
-	flag               string
-	params             map[string]bool
+	header             string
+	state              parserState
+	keyStart           int
+	keyEnd             int
+	valueStart         int
+	valueEnd           int
+	escaped            bool   // value contains escaped symbols & is kept unescaped in buf
+	buf                []byte // unescaped value
+	seen               uint8  // bit mask of known params found in the header
+	strict             bool
 }
 
-// NewParser create new parser
+// NewParser create new lenient parser
 func NewParser() *Parser {
-	p := new(Parser)
-	p.params = make(map[string]bool)
+	return new(Parser)
+}
+
+// NewStrictParser create new strict parser
+func NewStrictParser() *Parser {
+	p := NewParser()
+	p.strict = true
+	return p
+}
+
+// Reset clear parser state, so it could be reused for another header. Mode of the parser is kept
+func (p *Parser) Reset() {
+	*p = Parser{buf: p.buf[:0], strict: p.strict}
+}
+
+// acquireParser get parser from the pool
+func acquireParser(strict bool) *Parser {
+	p := parserPool.Get().(*Parser)
+	p.Reset()
+	p.strict = strict
 	return p
 }
 
+// releaseParser put parser back to the pool, parsed headers stay valid
+func releaseParser(p *Parser) {
+	parserPool.Put(p)
+}
+
 // ParseAuthorizationHeader parse Authorization header
 func (p *Parser) ParseAuthorizationHeader(header string) (ParsedHeader, *ParserError) {
-	p.flag = "keyword"
+	p.Reset()
+	p.state = stateKeyword
 	return p.parseSignature(header)
 }
 
 // ParseSignatureHeader parse Signature header
 func (p *Parser) ParseSignatureHeader(header string) (ParsedHeader, *ParserError) {
-	p.flag = "param"
+	p.Reset()
+	p.state = stateParam
 	return p.parseSignature(header)
 }
 
 // ParseDigestHeader parse Digest header
 func (p *Parser) ParseDigestHeader(header string) (ParsedDigestHeader, *ParserError) {
-	p.flag = "algorithm"
+	p.Reset()
+	p.state = stateAlgorithm
 	return p.parseDigest(header)
 }
 
@@ -99,47 +255,43 @@ func (p *Parser) parseSignature(header string) (ParsedHeader, *ParserError) {
 	}
 
 	var err *ParserError
-	r := strings.NewReader(header)
-	b := make([]byte, 1)
-	for {
-		_, rErr := r.Read(b)
-		if rErr == io.EOF {
-			err = p.handleSignatureEOF()
-			if err != nil {
-				return ParsedHeader{}, err
-			}
-			break
-		}
-
-		cur := b[0]
-		switch p.flag {
-		case "keyword":
-			err = p.parseKeyword(cur)
-		case "param":
-			err = p.parseKey(cur)
-		case "equal":
+	p.header = header
+	for i := 0; i < len(header); i++ {
+		cur := header[i]
+		switch p.state {
+		case stateKeyword:
+			err = p.parseKeyword(i, cur)
+		case stateParam:
+			err = p.parseKey(i, cur)
+		case stateEqual:
 			err = p.parseEqual(cur)
-		case "quote":
+		case stateQuote:
 			err = p.parseQuote(cur)
-		case "stringValue":
-			err = p.parseStringValue(cur)
-		case "intValue":
-			err = p.parseIntValue(cur)
-		case "div":
+		case stateStringValue:
+			err = p.parseStringValue(i, cur)
+		case stateQuotedPair:
+			err = p.parseQuotedPair(i, cur)
+		case stateDecimalValue:
+			err = p.parseDecimalValue(i, cur)
+		case stateQuotedDecimalValue:
+			err = p.parseQuotedDecimalValue(i, cur)
+		case stateDiv:
 			err = p.parseDiv(cur)
 		default:
 			err = &ParserError{"unexpected parser stage", nil}
-
 		}
 		if err != nil {
 			return ParsedHeader{}, err
 		}
 	}
+	if err = p.handleSignatureEOF(); err != nil {
+		return ParsedHeader{}, err
+	}
 
 	// 2.1.6 If not specified, implementations MUST operate as if the field were specified with a
 	// single value, `(created)`, in the list of HTTP headers.
 	if len(p.parsedHeader.headers) == 0 {
-		p.parsedHeader.headers = append(p.parsedHeader.headers, "(created)")
+		p.parsedHeader.headers = []string{created}
 	}
 
 	return p.parsedHeader, nil
@@ -151,54 +303,46 @@ func (p *Parser) parseDigest(header string) (ParsedDigestHeader, *ParserError) {
 	}
 
 	var err *ParserError
-	r := strings.NewReader(header)
-	b := make([]byte, 1)
-	for {
-		_, rErr := r.Read(b)
-		if rErr == io.EOF {
-			err = p.handleDigestEOF()
-			if err != nil {
-				return ParsedDigestHeader{}, err
-			}
-			break
-		}
-
-		cur := b[0]
-		switch p.flag {
-		case "algorithm":
-			err = p.parseAlgorithm(cur)
-		case "stringRawValue":
-			err = p.parseStringRawValue(cur)
+	p.header = header
+	for i := 0; i < len(header); i++ {
+		cur := header[i]
+		switch p.state {
+		case stateAlgorithm:
+			err = p.parseAlgorithm(i, cur)
+		case stateRawValue:
+			p.appendValue(i, cur)
 		default:
 			err = &ParserError{"unexpected parser stage", nil}
-
 		}
 		if err != nil {
 			return ParsedDigestHeader{}, err
 		}
 	}
+	if err = p.handleDigestEOF(); err != nil {
+		return ParsedDigestHeader{}, err
+	}
 
 	return p.parsedDigestHeader, nil
 }
 
 func (p *Parser) handleSignatureEOF() *ParserError {
 	var err *ParserError
-	switch p.flag {
-	case "keyword":
+	switch p.state {
+	case stateKeyword:
 		err = p.setKeyword()
-	case "param":
-		if len(p.key) == 0 {
+	case stateParam:
+		if p.keyStart == p.keyEnd {
 			err = &ParserError{"unexpected end of header, expected parameter", nil}
 		} else {
 			err = &ParserError{"unexpected end of header, expected '=' symbol and field value", nil}
 		}
-	case "equal":
+	case stateEqual:
 		err = &ParserError{"unexpected end of header, expected field value", nil}
-	case "quote":
+	case stateQuote:
 		err = &ParserError{"unexpected end of header, expected '\"' symbol and field value", nil}
-	case "stringValue":
+	case stateStringValue, stateQuotedPair, stateQuotedDecimalValue:
 		err = &ParserError{"unexpected end of header, expected '\"' symbol", nil}
-	case "intValue":
+	case stateDecimalValue:
 		err = p.setKeyValue()
 	}
 	return err
@@ -206,39 +350,37 @@ func (p *Parser) handleSignatureEOF() *ParserError {
 
 func (p *Parser) handleDigestEOF() *ParserError {
 	var err *ParserError
-	if p.flag == "algorithm" {
+	if p.state == stateAlgorithm {
 		err = &ParserError{"unexpected end of header, expected digest value", nil}
-	} else if p.flag == "stringRawValue" {
+	} else if p.state == stateRawValue {
 		err = p.setDigest()
 	}
 	return err
 }
 
-func (p *Parser) parseKeyword(cur byte) *ParserError {
+func (p *Parser) parseKeyword(i int, cur byte) *ParserError {
 	if (cur >= fromA && cur <= toZ) || (cur >= froma && cur <= toz) {
-		p.keyword = append(p.keyword, cur)
-	} else if cur == space && len(p.keyword) > 0 {
-		p.flag = "param"
-		if err := p.setKeyword(); err != nil {
-			return err
+		p.appendKey(i)
+	} else if isWhitespace(cur) {
+		if p.keyStart == p.keyEnd {
+			return nil
 		}
+		p.state = stateParam
+		return p.setKeyword()
+	} else {
+		return p.setKeyword()
 	}
 	return nil
 }
 
-func (p *Parser) parseKey(cur byte) *ParserError {
-	if (cur >= fromA && cur <= toZ) || (cur >= froma && cur <= toz) {
-		p.key = append(p.key, cur)
+func (p *Parser) parseKey(i int, cur byte) *ParserError {
+	if isTokenChar(cur) {
+		p.appendKey(i)
 	} else if cur == equal {
-		t := p.getValueType()
-		if t == "string" {
-			p.flag = "quote"
-		} else if t == "int" {
-			p.flag = "intValue"
-		}
-	} else if cur == space && len(p.key) > 0 {
-		p.flag = "equal"
-	} else if cur != space {
+		p.state = p.valueState()
+	} else if isWhitespace(cur) && p.keyStart != p.keyEnd {
+		p.state = stateEqual
+	} else if !isWhitespace(cur) {
 		return &ParserError{
 			fmt.Sprintf("found '%s' — unsupported symbol in key", string(cur)),
 			nil,
@@ -247,13 +389,16 @@ func (p *Parser) parseKey(cur byte) *ParserError {
 	return nil
 }
 
-func (p *Parser) parseAlgorithm(cur byte) *ParserError {
+func (p *Parser) parseAlgorithm(i int, cur byte) *ParserError {
 	if (cur >= fromA && cur <= toZ) ||
 		(cur >= froma && cur <= toz) ||
 		(cur >= from0 && cur <= to9) || cur == min {
-		p.key = append(p.key, cur)
+		p.appendKey(i)
 	} else if cur == equal {
-		p.flag = "stringRawValue"
+		if p.keyStart == p.keyEnd {
+			return &ParserError{"empty digest algorithm", nil}
+		}
+		p.state = stateRawValue
 	} else {
 		return &ParserError{
 			fmt.Sprintf("found '%s' — unsupported symbol in algorithm", string(cur)),
@@ -265,15 +410,8 @@ func (p *Parser) parseAlgorithm(cur byte) *ParserError {
 
 func (p *Parser) parseEqual(cur byte) *ParserError {
 	if cur == equal {
-		t := p.getValueType()
-		if t == "string" {
-			p.flag = "quote"
-		} else if t == "int" {
-			p.flag = "intValue"
-		}
-	} else if cur == space {
-		return nil
-	} else {
+		p.state = p.valueState()
+	} else if !isWhitespace(cur) {
 		return &ParserError{
 			fmt.Sprintf("found '%s' — unsupported symbol, expected '=' or space symbol", string(cur)),
 			nil,
@@ -284,10 +422,8 @@ func (p *Parser) parseEqual(cur byte) *ParserError {
 
 func (p *Parser) parseQuote(cur byte) *ParserError {
 	if cur == quote {
-		p.flag = "stringValue"
-	} else if cur == space {
-		return nil
-	} else {
+		p.state = stateStringValue
+	} else if !isWhitespace(cur) {
 		return &ParserError{
 			fmt.Sprintf("found '%s' — unsupported symbol, expected '\"' or space symbol", string(cur)),
 			nil,
@@ -296,49 +432,86 @@ func (p *Parser) parseQuote(cur byte) *ParserError {
 	return nil
 }
 
-func (p *Parser) parseStringValue(cur byte) *ParserError {
-	if cur != quote {
-		p.value = append(p.value, cur)
-	} else if cur == quote {
-		p.flag = "div"
-		if err := p.setKeyValue(); err != nil {
-			return err
+func (p *Parser) parseStringValue(i int, cur byte) *ParserError {
+	if cur == quote {
+		p.state = stateDiv
+		return p.setKeyValue()
+	}
+	if cur == bsl {
+		if !p.escaped {
+			p.buf = append(p.buf[:0], p.header[p.valueStart:p.valueEnd]...)
+			p.escaped = true
 		}
+		p.state = stateQuotedPair
+		return nil
 	}
+	// qdtext = HTAB / SP / %x21 / %x23-5B / %x5D-7E / obs-text
+	if p.strict && isControlChar(cur) {
+		return &ParserError{
+			fmt.Sprintf("found %q — unsupported symbol in '%s' value", cur, p.keyName()),
+			nil,
+		}
+	}
+	p.appendValue(i, cur)
 	return nil
 }
 
-func (p *Parser) parseIntValue(cur byte) *ParserError {
-	if cur >= from0 && cur <= to9 {
-		p.value = append(p.value, cur)
-	} else if cur == space {
-		if len(p.value) == 0 {
-			return nil
+// parseQuotedPair unescape symbol after backslash: quoted-pair = "\" ( HTAB / SP / VCHAR / obs-text )
+func (p *Parser) parseQuotedPair(i int, cur byte) *ParserError {
+	if p.strict && isControlChar(cur) {
+		return &ParserError{
+			fmt.Sprintf("found %q — unsupported escaped symbol in '%s' value", cur, p.keyName()),
+			nil,
 		}
-		p.flag = "div"
-		if err := p.setKeyValue(); err != nil {
-			return err
+	}
+	p.appendValue(i, cur)
+	p.state = stateStringValue
+	return nil
+}
+
+func (p *Parser) parseDecimalValue(i int, cur byte) *ParserError {
+	if (cur >= from0 && cur <= to9) || cur == dot {
+		p.appendValue(i, cur)
+	} else if cur == quote && !p.strict && !p.hasValue() {
+		p.state = stateQuotedDecimalValue
+	} else if isWhitespace(cur) {
+		if !p.hasValue() {
+			return nil
 		}
+		p.state = stateDiv
+		return p.setKeyValue()
 	} else if cur == div {
-		p.flag = "param"
-		if err := p.setKeyValue(); err != nil {
-			return err
+		p.state = stateParam
+		return p.setKeyValue()
+	} else {
+		return &ParserError{
+			fmt.Sprintf("found '%s' — unsupported symbol in '%s' value", string(cur), p.keyName()),
+			nil,
 		}
 	}
 	return nil
 }
 
-func (p *Parser) parseStringRawValue(cur byte) *ParserError {
-	p.value = append(p.value, cur)
+// parseQuotedDecimalValue created & expires value in quotes, allowed by lenient parser only
+func (p *Parser) parseQuotedDecimalValue(i int, cur byte) *ParserError {
+	if (cur >= from0 && cur <= to9) || cur == dot {
+		p.appendValue(i, cur)
+	} else if cur == quote {
+		p.state = stateDiv
+		return p.setKeyValue()
+	} else {
+		return &ParserError{
+			fmt.Sprintf("found '%s' — unsupported symbol in '%s' value", string(cur), p.keyName()),
+			nil,
+		}
+	}
 	return nil
 }
 
 func (p *Parser) parseDiv(cur byte) *ParserError {
 	if cur == div {
-		p.flag = "param"
-	} else if cur == space {
-		return nil
-	} else {
+		p.state = stateParam
+	} else if !isWhitespace(cur) {
 		return &ParserError{
 			fmt.Sprintf("found '%s' — unsupported symbol, expected ',' or space symbol", string(cur)),
 			nil,
@@ -347,99 +520,225 @@ func (p *Parser) parseDiv(cur byte) *ParserError {
 	return nil
 }
 
-func (p *Parser) getValueType() string {
-	k := string(p.key)
-	if k == "created" || k == "expires" {
-		return "int"
+// appendKey extend current key (keyword or param name) with symbol at position i
+func (p *Parser) appendKey(i int) {
+	if p.keyStart == p.keyEnd {
+		p.keyStart = i
+	}
+	p.keyEnd = i + 1
+}
+
+// appendValue extend current value with symbol at position i
+func (p *Parser) appendValue(i int, cur byte) {
+	if p.escaped {
+		p.buf = append(p.buf, cur)
+		return
+	}
+	if p.valueStart == p.valueEnd {
+		p.valueStart = i
+	}
+	p.valueEnd = i + 1
+}
+
+func (p *Parser) hasValue() bool {
+	if p.escaped {
+		return len(p.buf) > 0
+	}
+	return p.valueStart != p.valueEnd
+}
+
+func (p *Parser) keyName() string {
+	return p.header[p.keyStart:p.keyEnd]
+}
+
+func (p *Parser) value() string {
+	if p.escaped {
+		return string(p.buf)
 	}
-	return "string"
+	return p.header[p.valueStart:p.valueEnd]
+}
+
+// resetKeyValue clear current key & value before the next param
+func (p *Parser) resetKeyValue() {
+	p.keyStart, p.keyEnd = 0, 0
+	p.valueStart, p.valueEnd = 0, 0
+	p.escaped = false
+	p.buf = p.buf[:0]
+}
+
+// valueState stage after '=' symbol: created & expires are decimal values, other params are quoted strings
+func (p *Parser) valueState() parserState {
+	switch p.knownParam() {
+	case paramCreated, paramExpires:
+		return stateDecimalValue
+	default:
+		return stateQuote
+	}
+}
+
+// knownParam index of the current param in knownParams (-1 for unknown param).
+// Known params are matched case-insensitively by the lenient parser
+func (p *Parser) knownParam() int {
+	k := p.keyName()
+	for i, name := range knownParams {
+		if k == name || (!p.strict && strings.EqualFold(k, name)) {
+			return i
+		}
+	}
+	return -1
 }
 
 func (p *Parser) setKeyword() *ParserError {
-	if "Signature" != string(p.keyword) {
+	// Authentication scheme is case-insensitive (RFC 7235 2.1)
+	if !strings.EqualFold(p.keyName(), authorizationScheme) {
 		return &ParserError{
 			"invalid Authorization header, must start from Signature keyword",
 			nil,
 		}
 	}
-	p.parsedHeader.keyword = "Signature"
+	p.parsedHeader.keyword = authorizationScheme
+	p.keyStart, p.keyEnd = 0, 0
 	return nil
 }
 
 func (p *Parser) setKeyValue() *ParserError {
-	k := string(p.key)
+	idx := p.knownParam()
+	k := p.keyName()
+	if idx != -1 {
+		k = knownParams[idx]
+	}
 
-	if len(p.value) == 0 {
+	if !p.hasValue() {
 		return &ParserError{
 			fmt.Sprintf("empty value for key '%s'", k),
 			nil,
 		}
 	}
 
-	if p.params[k] {
-		// 2.2 If any of the parameters listed above are erroneously duplicated in the associated header field,
-		// then the the signature MUST NOT be processed.
+	// 2.2 If any of the parameters listed above are erroneously duplicated in the associated header field,
+	// then the the signature MUST NOT be processed.
+	duplicate := false
+	if idx != -1 {
+		duplicate = p.seen&(1<<uint(idx)) != 0
+		p.seen |= 1 << uint(idx)
+	} else {
+		_, duplicate = p.parsedHeader.params[k]
+	}
+	if duplicate {
 		return &ParserError{
 			fmt.Sprintf("duplicate param '%s'", k),
 			nil,
 		}
 	}
-	p.params[k] = true
 
-	if k == "keyId" {
-		p.parsedHeader.keyID = string(p.value)
-	} else if k == "algorithm" {
-		p.parsedHeader.algorithm = string(p.value)
-	} else if k == "headers" {
-		p.parsedHeader.headers = strings.Fields(string(p.value))
-	} else if k == "signature" {
-		p.parsedHeader.signature = string(p.value)
-	} else if k == "created" {
-		var err error
-		if p.parsedHeader.created, err = p.intToTime(p.value); err != nil {
+	v := p.value()
+	var err error
+	switch idx {
+	case paramKeyID:
+		p.parsedHeader.keyID = v
+	case paramAlgorithm:
+		p.parsedHeader.algorithm = v
+	case paramCreated:
+		// 2.1.4 created MUST be a Unix timestamp integer value
+		if p.strict && strings.IndexByte(v, dot) != -1 {
+			return &ParserError{
+				"wrong 'created' param value",
+				fmt.Errorf("decimal value %q not allowed", v),
+			}
+		}
+		if p.parsedHeader.created, err = p.decimalToTime(v); err != nil {
 			return &ParserError{"wrong 'created' param value", err}
 		}
-	} else if k == "expires" {
-		var err error
-		if p.parsedHeader.expires, err = p.intToTime(p.value); err != nil {
+	case paramExpires:
+		if p.parsedHeader.expires, err = p.decimalToTime(v); err != nil {
 			return &ParserError{"wrong 'expires' param value", err}
 		}
+	case paramHeaders:
+		p.parsedHeader.headers = strings.Fields(v)
+	case paramSignature:
+		p.parsedHeader.signature = v
+	default:
+		// 2.2 Any parameter that is not recognized as a parameter, or is not well-formed, MUST be ignored.
+		// Unrecognised params are ignored during verification, but kept for the caller.
+		if p.parsedHeader.params == nil {
+			p.parsedHeader.params = make(map[string]string)
+		}
+		p.parsedHeader.params[k] = v
 	}
 
-	// 2.2 Any parameter that is not recognized as a parameter, or is not well-formed, MUST be ignored.
-
-	p.key = nil
-	p.value = nil
+	p.resetKeyValue()
 
 	return nil
 }
 
-func (p *Parser) intToTime(v []byte) (time.Time, error) {
-	var err error
-	var sec int64
-	if sec, err = strconv.ParseInt(string(v), 10, 32); err != nil {
+// decimalToTime convert Unix time with optional fraction of second (e.g. `1402170695.25`) to time
+func (p *Parser) decimalToTime(v string) (time.Time, error) {
+	s := v
+	var frac string
+	if i := strings.IndexByte(s, dot); i != -1 {
+		s, frac = s[:i], s[i+1:]
+		if len(s) == 0 || len(frac) == 0 || strings.IndexByte(frac, dot) != -1 {
+			return time.Unix(0, 0), fmt.Errorf("wrong decimal value %q", v)
+		}
+	}
+
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
 		return time.Unix(0, 0), err
 	}
-	return time.Unix(sec, 0), nil
+	if sec > maxTimestamp {
+		return time.Unix(0, 0), fmt.Errorf("timestamp %d out of range", sec)
+	}
+	var nsec int64
+	if len(frac) > 0 {
+		// Precision beyond nanoseconds is truncated
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		if nsec, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Unix(0, 0), err
+		}
+		for n := len(frac); n < 9; n++ {
+			nsec *= 10
+		}
+	}
+	return time.Unix(sec, nsec), nil
 }
 
 func (p *Parser) setDigest() *ParserError {
-	if len(p.value) == 0 {
+	if !p.hasValue() {
 		return &ParserError{
 			"empty digest value",
 			nil,
 		}
 	}
 
-	p.parsedDigestHeader.algo = strings.ToUpper(string(p.key))
-	p.parsedDigestHeader.digest = string(p.value)
+	p.parsedDigestHeader.algo = strings.ToUpper(p.keyName())
+	p.parsedDigestHeader.digest = p.value()
 
-	p.key = nil
-	p.value = nil
+	p.resetKeyValue()
 
 	return nil
 }
 
+// isWhitespace OWS & BWS symbols: SP & HTAB
+func isWhitespace(c byte) bool {
+	return c == space || c == tab
+}
+
+// isTokenChar tchar of the token ABNF rule (RFC 7230 3.2.6)
+func isTokenChar(c byte) bool {
+	if (c >= fromA && c <= toZ) || (c >= froma && c <= toz) || (c >= from0 && c <= to9) {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) != -1
+}
+
+// isControlChar control symbols not allowed in quoted strings (HTAB is allowed)
+func isControlChar(c byte) bool {
+	return (c < space && c != tab) || c == del
+}
+
 // VerifySignatureFields verify required fields
 func (p *Parser) VerifySignatureFields() *ParserError {
 	if p.parsedHeader.keyID == "" {
@@ -28,9 +28,7 @@ func TestNew(t *testing.T) {
 	}{
 		{
 			name: "Successful",
-			want: &Parser{
-				params: make(map[string]bool),
-			},
+			want: &Parser{},
 		},
 	}
 	for _, tt := range tests {
@@ -121,6 +119,20 @@ func TestParserParseSingleFields(t *testing.T) {
 			wantErrType: parserErrType,
 			wantErrMsg:  "",
 		},
+		{
+			name: "Authorization: Case-insensitive Signature keyword",
+			args: args{
+				header:        `SIGNATURE keyId="v1"`,
+				authorization: true,
+			},
+			want: ParsedHeader{
+				keyword: "Signature",
+				keyID:   "v1",
+				headers: validHeadersIfNotSpecified,
+			},
+			wantErrType: parserErrType,
+			wantErrMsg:  "",
+		},
 		{
 			name: "Authorization: Signature and algorithm",
 			args: args{
@@ -218,11 +230,23 @@ func TestParserParseSingleFields(t *testing.T) {
 		{
 			name: "Unsupported symbol in key",
 			args: args{
-				header: `keyId-="v1"`,
+				header: `keyId@="v1"`,
 			},
 			want:        ParsedHeader{},
 			wantErrType: parserErrType,
-			wantErrMsg:  "ParserError: found '-' — unsupported symbol in key",
+			wantErrMsg:  "ParserError: found '@' — unsupported symbol in key",
+		},
+		{
+			name: "Token symbols in key",
+			args: args{
+				header: `x-nonce_1.v2="v1"`,
+			},
+			want: ParsedHeader{
+				headers: validHeadersIfNotSpecified,
+				params:  map[string]string{"x-nonce_1.v2": "v1"},
+			},
+			wantErrType: parserErrType,
+			wantErrMsg:  "",
 		},
 		{
 			name: "Unsupported symbol, expected = symbol",
@@ -249,6 +273,7 @@ func TestParserParseSingleFields(t *testing.T) {
 			},
 			want: ParsedHeader{
 				headers: validHeadersIfNotSpecified,
+				params:  map[string]string{"key": "v1"},
 			},
 			wantErrType: parserErrType,
 			wantErrMsg:  "",
@@ -312,9 +337,9 @@ func TestParserParseSingleFields(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			p := NewParser()
 			if true == tt.args.authorization {
-				p.flag = "keyword"
+				p.state = stateKeyword
 			} else {
-				p.flag = "param"
+				p.state = stateParam
 			}
 			var got, err = p.parseSignature(tt.args.header)
 			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
@@ -357,6 +382,70 @@ func TestParserParseCreatedExpires(t *testing.T) {
 			wantErrType: parserErrType,
 			wantErrMsg:  "",
 		},
+		{
+			name: "Decimal created & expires",
+			args: args{
+				header: `created=1402170695.5,expires=1402170699.123456789`,
+			},
+			want: ParsedHeader{
+				created: time.Unix(1402170695, 500000000),
+				expires: time.Unix(1402170699, 123456789),
+				headers: validHeadersIfNotSpecified,
+			},
+			wantErrType: parserErrType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Decimal expires beyond nanoseconds",
+			args: args{
+				header: `expires=1402170699.0000000019 `,
+			},
+			want: ParsedHeader{
+				expires: time.Unix(1402170699, 1),
+				headers: validHeadersIfNotSpecified,
+			},
+			wantErrType: parserErrType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Created after 2038",
+			args: args{
+				header: `created=4102444800`,
+			},
+			want: ParsedHeader{
+				created: time.Unix(4102444800, 0),
+				headers: validHeadersIfNotSpecified,
+			},
+			wantErrType: parserErrType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Wrong decimal created value",
+			args: args{
+				header: `created=1402170695.5.5`,
+			},
+			want:        ParsedHeader{},
+			wantErrType: parserErrType,
+			wantErrMsg:  "ParserError: wrong 'created' param value: wrong decimal value \"1402170695.5.5\"",
+		},
+		{
+			name: "Decimal expires without fraction",
+			args: args{
+				header: `expires=1402170699.`,
+			},
+			want:        ParsedHeader{},
+			wantErrType: parserErrType,
+			wantErrMsg:  "ParserError: wrong 'expires' param value: wrong decimal value \"1402170699.\"",
+		},
+		{
+			name: "Unsupported symbol in created value",
+			args: args{
+				header: `created=1402170695a`,
+			},
+			want:        ParsedHeader{},
+			wantErrType: parserErrType,
+			wantErrMsg:  "ParserError: found 'a' — unsupported symbol in 'created' value",
+		},
 		{
 			name: "Wrong created INT value",
 			args: args{
@@ -364,7 +453,7 @@ func TestParserParseCreatedExpires(t *testing.T) {
 			},
 			want:        ParsedHeader{},
 			wantErrType: parserErrType,
-			wantErrMsg:  "ParserError: wrong 'created' param value: strconv.ParseInt: parsing \"9223372036854775807\": value out of range",
+			wantErrMsg:  "ParserError: wrong 'created' param value: timestamp 9223372036854775807 out of range",
 		},
 		{
 			name: "Wrong created INT value with space at the end",
@@ -391,7 +480,7 @@ func TestParserParseCreatedExpires(t *testing.T) {
 			},
 			want:        ParsedHeader{},
 			wantErrType: parserErrType,
-			wantErrMsg:  "ParserError: wrong 'expires' param value: strconv.ParseInt: parsing \"9223372036854775807\": value out of range",
+			wantErrMsg:  "ParserError: wrong 'expires' param value: timestamp 9223372036854775807 out of range",
 		},
 		{
 			name: "Wrong expires with space at the end",
@@ -415,7 +504,7 @@ func TestParserParseCreatedExpires(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := NewParser()
-			p.flag = "param"
+			p.state = stateParam
 			var got, err = p.parseSignature(tt.args.header)
 			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
 		})
@@ -565,6 +654,7 @@ func TestParserParseAmbiguousParams(t *testing.T) {
 			want: ParsedHeader{
 				keyID:   "v1",
 				headers: validHeadersIfNotSpecified,
+				params:  map[string]string{"ambiguous": "v2", "digest": "v3"},
 			},
 			wantErrType: parserErrType,
 			wantErrMsg:  "",
@@ -648,7 +738,7 @@ func TestParserParseDuplicateParams(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := NewParser()
-			p.flag = "param"
+			p.state = stateParam
 			got, err := p.parseSignature(tt.args.header)
 			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
 		})
@@ -769,6 +859,15 @@ func TestParserParseDigestHeader(t *testing.T) {
 			wantErrType: parserErrType,
 			wantErrMsg:  "ParserError: found ' ' — unsupported symbol in algorithm",
 		},
+		{
+			name: "Empty digest algorithm",
+			args: args{
+				header: `=ZDk5NTk4ODgxNjM3MDc5MDQ2MTgzNDQwMzExMThiZWI=`,
+			},
+			want:        ParsedDigestHeader{},
+			wantErrType: parserErrType,
+			wantErrMsg:  "ParserError: empty digest algorithm",
+		},
 		{
 			name: "Empty digest value",
 			args: args{
@@ -837,3 +936,364 @@ func TestVerifySignatureFields(t *testing.T) {
 		})
 	}
 }
+
+func TestParsedHeaderAccessors(t *testing.T) {
+	p := NewParser()
+	ph, err := p.ParseAuthorizationHeader(`Signature keyId="Test",algorithm="rsa-sha256",created=1402170695,` +
+		`expires=1402170699.5,headers="(request-target) host",signature="c2ln",nonce="n1"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := []interface{}{
+		ph.Keyword(), ph.KeyID(), ph.Algorithm(), ph.Created(), ph.Expires(), ph.Headers(), ph.Signature(), ph.Params(),
+	}
+	want := []interface{}{
+		"Signature", "Test", "rsa-sha256", time.Unix(1402170695, 0), time.Unix(1402170699, 500000000),
+		[]string{"(request-target)", "host"}, "c2ln", map[string]string{"nonce": "n1"},
+	}
+	assert(t, got, nil, parserErrType, "Accessors", want, "")
+
+	// Returned slices & maps are copies
+	ph.Headers()[0] = "date"
+	ph.Params()["nonce"] = "n2"
+	assert(t, []interface{}{ph.Headers()[0], ph.Params()["nonce"]}, nil, parserErrType, "Copies",
+		[]interface{}{"(request-target)", "n1"}, "")
+
+	pd, err := p.ParseDigestHeader("sha-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	assert(t, []string{pd.Algorithm(), pd.Digest()}, nil, parserErrType, "Digest accessors",
+		[]string{"SHA-256", "X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="}, "")
+}
+
+func TestParserParseModes(t *testing.T) {
+	type args struct {
+		header        string
+		authorization bool
+	}
+	tests := []struct {
+		name             string
+		args             args
+		want             ParsedHeader
+		wantErrMsg       string
+		wantStrict       ParsedHeader
+		wantStrictErrMsg string
+	}{
+		{
+			name: "Valid header",
+			args: args{
+				header: validSignatureHeader,
+			},
+			want:       validParsedSignatureHeader,
+			wantStrict: validParsedSignatureHeader,
+		},
+		{
+			name: "Authorization: keyword & params separated by tabs",
+			args: args{
+				header:        "Signature\tkeyId\t=\t\"v1\",\talgorithm=\"v2\"\t",
+				authorization: true,
+			},
+			want: ParsedHeader{
+				keyword:   "Signature",
+				keyID:     "v1",
+				algorithm: "v2",
+				headers:   validHeadersIfNotSpecified,
+			},
+			wantStrict: ParsedHeader{
+				keyword:   "Signature",
+				keyID:     "v1",
+				algorithm: "v2",
+				headers:   validHeadersIfNotSpecified,
+			},
+		},
+		{
+			name: "Escaped quote",
+			args: args{
+				header: `keyId="a\"b"`,
+			},
+			want:       ParsedHeader{keyID: `a"b`, headers: validHeadersIfNotSpecified},
+			wantStrict: ParsedHeader{keyID: `a"b`, headers: validHeadersIfNotSpecified},
+		},
+		{
+			name: "Escaped backslash",
+			args: args{
+				header: `keyId="a\\b"`,
+			},
+			want:       ParsedHeader{keyID: `a\b`, headers: validHeadersIfNotSpecified},
+			wantStrict: ParsedHeader{keyID: `a\b`, headers: validHeadersIfNotSpecified},
+		},
+		{
+			name: "Escaped letter",
+			args: args{
+				header: `keyId="\a\b"`,
+			},
+			want:       ParsedHeader{keyID: `ab`, headers: validHeadersIfNotSpecified},
+			wantStrict: ParsedHeader{keyID: `ab`, headers: validHeadersIfNotSpecified},
+		},
+		{
+			name: "Escaped space & tab",
+			args: args{
+				header: "keyId=\"a\\ b\\\tc\"",
+			},
+			want:       ParsedHeader{keyID: "a b\tc", headers: validHeadersIfNotSpecified},
+			wantStrict: ParsedHeader{keyID: "a b\tc", headers: validHeadersIfNotSpecified},
+		},
+		{
+			name: "Escaped control symbol",
+			args: args{
+				header: "keyId=\"a\\\x01\"",
+			},
+			want:             ParsedHeader{keyID: "a\x01", headers: validHeadersIfNotSpecified},
+			wantStrictErrMsg: `ParserError: found '\x01' — unsupported escaped symbol in 'keyId' value`,
+		},
+		{
+			name: "Backslash at the end",
+			args: args{
+				header: `keyId="a\`,
+			},
+			wantErrMsg:       "ParserError: unexpected end of header, expected '\"' symbol",
+			wantStrictErrMsg: "ParserError: unexpected end of header, expected '\"' symbol",
+		},
+		{
+			name: "Escaped closing quote",
+			args: args{
+				header: `keyId="a\"`,
+			},
+			wantErrMsg:       "ParserError: unexpected end of header, expected '\"' symbol",
+			wantStrictErrMsg: "ParserError: unexpected end of header, expected '\"' symbol",
+		},
+		{
+			name: "Control symbol in value",
+			args: args{
+				header: "keyId=\"a\x00b\"",
+			},
+			want:             ParsedHeader{keyID: "a\x00b", headers: validHeadersIfNotSpecified},
+			wantStrictErrMsg: `ParserError: found '\x00' — unsupported symbol in 'keyId' value`,
+		},
+		{
+			name: "Line feed in value",
+			args: args{
+				header: "keyId=\"a\nb\"",
+			},
+			want:             ParsedHeader{keyID: "a\nb", headers: validHeadersIfNotSpecified},
+			wantStrictErrMsg: `ParserError: found '\n' — unsupported symbol in 'keyId' value`,
+		},
+		{
+			name: "DEL symbol in value",
+			args: args{
+				header: "keyId=\"a\x7fb\"",
+			},
+			want:             ParsedHeader{keyID: "a\x7fb", headers: validHeadersIfNotSpecified},
+			wantStrictErrMsg: `ParserError: found '\x7f' — unsupported symbol in 'keyId' value`,
+		},
+		{
+			name: "Tab in value",
+			args: args{
+				header: "keyId=\"a\tb\"",
+			},
+			want:       ParsedHeader{keyID: "a\tb", headers: validHeadersIfNotSpecified},
+			wantStrict: ParsedHeader{keyID: "a\tb", headers: validHeadersIfNotSpecified},
+		},
+		{
+			name: "obs-text in value",
+			args: args{
+				header: "keyId=\"caf\xe9\"",
+			},
+			want:       ParsedHeader{keyID: "caf\xe9", headers: validHeadersIfNotSpecified},
+			wantStrict: ParsedHeader{keyID: "caf\xe9", headers: validHeadersIfNotSpecified},
+		},
+		{
+			name: "Token symbols in param name",
+			args: args{
+				header: "x-tag!#$%&'*+.^_`|~1=\"v1\"",
+			},
+			want: ParsedHeader{
+				headers: validHeadersIfNotSpecified,
+				params:  map[string]string{"x-tag!#$%&'*+.^_`|~1": "v1"},
+			},
+			wantStrict: ParsedHeader{
+				headers: validHeadersIfNotSpecified,
+				params:  map[string]string{"x-tag!#$%&'*+.^_`|~1": "v1"},
+			},
+		},
+		{
+			name: "Separator in param name",
+			args: args{
+				header: `key(1)="v1"`,
+			},
+			wantErrMsg:       "ParserError: found '(' — unsupported symbol in key",
+			wantStrictErrMsg: "ParserError: found '(' — unsupported symbol in key",
+		},
+		{
+			name: "Known params in different case",
+			args: args{
+				header: `keyid="v1",ALGORITHM="v2",Created=1402170695,Headers="date",SIGNATURE="v3"`,
+			},
+			want: ParsedHeader{
+				keyID:     "v1",
+				algorithm: "v2",
+				created:   time.Unix(1402170695, 0),
+				headers:   []string{"date"},
+				signature: "v3",
+			},
+			wantStrictErrMsg: "ParserError: found '1' — unsupported symbol, expected '\"' or space symbol",
+		},
+		{
+			name: "Known string params in different case",
+			args: args{
+				header: `keyid="v1",Signature="v2"`,
+			},
+			want: ParsedHeader{
+				keyID:     "v1",
+				signature: "v2",
+				headers:   validHeadersIfNotSpecified,
+			},
+			wantStrict: ParsedHeader{
+				headers: validHeadersIfNotSpecified,
+				params:  map[string]string{"keyid": "v1", "Signature": "v2"},
+			},
+		},
+		{
+			name: "Duplicate params in different case",
+			args: args{
+				header: `keyId="v1",keyid="v2"`,
+			},
+			wantErrMsg: "ParserError: duplicate param 'keyId'",
+			wantStrict: ParsedHeader{
+				keyID:   "v1",
+				headers: validHeadersIfNotSpecified,
+				params:  map[string]string{"keyid": "v2"},
+			},
+		},
+		{
+			name: "Quoted created & expires",
+			args: args{
+				header: `created="1402170695", expires= "1402170699.5"`,
+			},
+			want: ParsedHeader{
+				created: time.Unix(1402170695, 0),
+				expires: time.Unix(1402170699, 500000000),
+				headers: validHeadersIfNotSpecified,
+			},
+			wantStrictErrMsg: "ParserError: found '\"' — unsupported symbol in 'created' value",
+		},
+		{
+			name: "Quoted created without closing quote",
+			args: args{
+				header: `created="1402170695`,
+			},
+			wantErrMsg:       "ParserError: unexpected end of header, expected '\"' symbol",
+			wantStrictErrMsg: "ParserError: found '\"' — unsupported symbol in 'created' value",
+		},
+		{
+			name: "Unsupported symbol in quoted created",
+			args: args{
+				header: `created="1402170695a"`,
+			},
+			wantErrMsg:       "ParserError: found 'a' — unsupported symbol in 'created' value",
+			wantStrictErrMsg: "ParserError: found '\"' — unsupported symbol in 'created' value",
+		},
+		{
+			name: "Empty quoted created",
+			args: args{
+				header: `created=""`,
+			},
+			wantErrMsg:       "ParserError: empty value for key 'created'",
+			wantStrictErrMsg: "ParserError: found '\"' — unsupported symbol in 'created' value",
+		},
+		{
+			name: "Decimal created",
+			args: args{
+				header: `created=1402170695.5`,
+			},
+			want: ParsedHeader{
+				created: time.Unix(1402170695, 500000000),
+				headers: validHeadersIfNotSpecified,
+			},
+			wantStrictErrMsg: "ParserError: wrong 'created' param value: decimal value \"1402170695.5\" not allowed",
+		},
+		{
+			name: "Decimal expires",
+			args: args{
+				header: `expires=1402170699.5`,
+			},
+			want: ParsedHeader{
+				expires: time.Unix(1402170699, 500000000),
+				headers: validHeadersIfNotSpecified,
+			},
+			wantStrict: ParsedHeader{
+				expires: time.Unix(1402170699, 500000000),
+				headers: validHeadersIfNotSpecified,
+			},
+		},
+		{
+			name: "Unquoted string value",
+			args: args{
+				header: `keyId=v1`,
+			},
+			wantErrMsg:       "ParserError: found 'v' — unsupported symbol, expected '\"' or space symbol",
+			wantStrictErrMsg: "ParserError: found 'v' — unsupported symbol, expected '\"' or space symbol",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parse := func(p *Parser) (ParsedHeader, *ParserError) {
+				if tt.args.authorization {
+					return p.ParseAuthorizationHeader(tt.args.header)
+				}
+				return p.ParseSignatureHeader(tt.args.header)
+			}
+			got, err := parse(NewParser())
+			assert(t, got, err, parserErrType, "Lenient: "+tt.name, tt.want, tt.wantErrMsg)
+			got, err = parse(NewStrictParser())
+			assert(t, got, err, parserErrType, "Strict: "+tt.name, tt.wantStrict, tt.wantStrictErrMsg)
+		})
+	}
+}
+
+func TestParserReset(t *testing.T) {
+	p := NewStrictParser()
+	first, err := p.ParseSignatureHeader(`keyId="a\"b",signature="v1"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	second, err := p.ParseSignatureHeader(`keyId="c\"d",x-tag="v2"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if first.KeyID() != `a"b` || first.Signature() != "v1" {
+		t.Errorf("first header changed after parser reuse: %v", first)
+	}
+	want := ParsedHeader{keyID: `c"d`, headers: validHeadersIfNotSpecified, params: map[string]string{"x-tag": "v2"}}
+	if !reflect.DeepEqual(second, want) {
+		t.Errorf("second header = %v, want %v", second, want)
+	}
+	p.Reset()
+	if !p.strict {
+		t.Error("parser mode is not kept after Reset")
+	}
+	if err := p.VerifySignatureFields(); err == nil {
+		t.Error("parsed header is not cleared after Reset")
+	}
+}
+
+func BenchmarkParseSignatureHeader(b *testing.B) {
+	p := NewParser()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.ParseSignatureHeader(validSignatureHeader); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseDigestHeader(b *testing.B) {
+	p := NewParser()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.ParseDigestHeader(`SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=`); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package httpsignatures
+
+import "strings"
+
+// Policy restrictions for algorithms and keys accepted during verification
+// DigestAlgorithms allowed digest hash algorithms (empty list allows all registered algorithms)
+// SignatureAlgorithms allowed signature algorithms (empty list allows all registered algorithms)
+// MinRSAKeySize minimal RSA key size in bits (0 disables the check)
+// RequiredComponents RFC 9421 components which must be covered by the signature, e.g. `@method`, `content-digest`
+// RequiredParams extension params which must be set in the signature, e.g. `nonce` or `tag`
+type Policy struct {
+	DigestAlgorithms    []string
+	SignatureAlgorithms []string
+	MinRSAKeySize       int
+	RequiredComponents  []string
+	RequiredParams      []string
+}
+
+// NewStrictPolicy create policy without weak algorithms: MD5 & SHA-1 digests are disabled,
+// RSA keys must be at least 2048 bits long
+func NewStrictPolicy() Policy {
+	return Policy{
+		DigestAlgorithms:    []string{algoSha256, algoSha512},
+		SignatureAlgorithms: []string{algoRsaSha256, algoHmacSha256, algoHmacSha512},
+		MinRSAKeySize:       2048,
+	}
+}
+
+// clone copy of the policy which doesn't share lists with the caller
+func (p Policy) clone() Policy {
+	p.DigestAlgorithms = append([]string(nil), p.DigestAlgorithms...)
+	p.SignatureAlgorithms = append([]string(nil), p.SignatureAlgorithms...)
+	p.RequiredComponents = append([]string(nil), p.RequiredComponents...)
+	p.RequiredParams = append([]string(nil), p.RequiredParams...)
+	return p
+}
+
+func (p Policy) isDigestAlgorithmAllowed(a string) bool {
+	return p.isAllowed(p.DigestAlgorithms, a)
+}
+
+func (p Policy) isSignatureAlgorithmAllowed(a string) bool {
+	return p.isAllowed(p.SignatureAlgorithms, a)
+}
+
+func (p Policy) isAllowed(list []string, a string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, a) {
+			return true
+		}
+	}
+	return false
+}
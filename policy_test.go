@@ -0,0 +1,308 @@
+package httpsignatures
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewStrictPolicy(t *testing.T) {
+	p := NewStrictPolicy()
+	tests := []struct {
+		name      string
+		algorithm string
+		digest    bool
+		want      bool
+	}{
+		{
+			name:      "MD5 digest not allowed",
+			algorithm: "md5",
+			digest:    true,
+			want:      false,
+		},
+		{
+			name:      "SHA-1 digest not allowed",
+			algorithm: "SHA-1",
+			digest:    true,
+			want:      false,
+		},
+		{
+			name:      "SHA-256 digest allowed",
+			algorithm: "sha-256",
+			digest:    true,
+			want:      true,
+		},
+		{
+			name:      "RSA-SHA256 signature allowed",
+			algorithm: "rsa-sha256",
+			want:      true,
+		},
+		{
+			name:      "Unknown signature not allowed",
+			algorithm: "RSA-SHA1",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			if tt.digest {
+				got = p.isDigestAlgorithmAllowed(tt.algorithm)
+			} else {
+				got = p.isSignatureAlgorithmAllowed(tt.algorithm)
+			}
+			if got != tt.want {
+				t.Errorf(tt.name+"\ngot  = %v,\nwant = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestPolicy(t *testing.T) {
+	type args struct {
+		r      *http.Request
+		policy Policy
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "MD5 allowed by default",
+			args: args{
+				r: getDigestRequestFunc(digestBodyExample, "MD5=Sd/dVLAcvNLSq16eXua5uQ=="),
+			},
+			want:        true,
+			wantErrType: digestErrType,
+		},
+		{
+			name: "MD5 disabled by strict policy",
+			args: args{
+				r:      getDigestRequestFunc(digestBodyExample, "MD5=Sd/dVLAcvNLSq16eXua5uQ=="),
+				policy: NewStrictPolicy(),
+			},
+			want:        false,
+			wantErrType: digestErrType,
+			wantErrMsg:  "DigestError: digest hash algorithm 'MD5' not allowed by policy",
+		},
+		{
+			name: "SHA-256 allowed by strict policy",
+			args: args{
+				r:      getDigestRequestFunc(digestBodyExample, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="),
+				policy: NewStrictPolicy(),
+			},
+			want:        true,
+			wantErrType: digestErrType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDigest()
+			d.SetPolicy(tt.args.policy)
+			err := d.Verify(tt.args.r)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestSetPolicyCopiesLists(t *testing.T) {
+	p := NewStrictPolicy()
+	d := NewDigest()
+	d.SetPolicy(p)
+	hs := NewHTTPSignatures(NewSecretsStorage(map[string]Secret{}))
+	hs.SetPolicy(p)
+
+	// Lists of the policy are changed by the caller after it's set
+	p.DigestAlgorithms[0] = "MD5"
+	p.SignatureAlgorithms[0] = "RSA-SHA1"
+
+	err := d.Verify(getDigestRequestFunc(digestBodyExample, "MD5=Sd/dVLAcvNLSq16eXua5uQ=="))
+	assert(t, false, err, digestErrType, "Digest policy", false,
+		"DigestError: digest hash algorithm 'MD5' not allowed by policy")
+	if hs.p.isDigestAlgorithmAllowed("MD5") || !hs.p.isSignatureAlgorithmAllowed("RSA-SHA256") ||
+		hs.p.isSignatureAlgorithmAllowed("RSA-SHA1") {
+		t.Errorf("HTTPSignatures policy changed by the caller: %v", hs.p)
+	}
+}
+
+func TestVerifySignaturePolicy(t *testing.T) {
+	ss := NewSecretsStorage(map[string]Secret{
+		"Test": {
+			KeyID:      "Test",
+			PrivateKey: rsaPrivateKey,
+			PublicKey:  rsaPublicKey,
+			Algorithm:  "RSA-SHA256",
+		},
+	})
+	getRequest := func() *http.Request {
+		r, _ := http.NewRequest(
+			http.MethodPost,
+			httpsignaturesHostExampleFull,
+			strings.NewReader(httpsignaturesBodyExample))
+		r.Header.Set("Signature", `keyId="Test",algorithm="rsa-sha256",headers="(request-target) host date",signature="qdx+H7PHHDZgy4y/Ahn9Tny9V3GP6YgBPyUXMmoxWtLbHpUnXS2mg2+SbrQDMCJypxBLSPQR2aAjn7ndmw2iicw3HMbe8VfEdKFYRqzic+efkb3nndiv/x1xSHDJWeSWkx3ButlYSuBskLu6kd9Fswtemr3lgdDEmn04swr2Os0="`)
+		r.Header.Set("Host", "example.com")
+		r.Header.Set("Date", "Sun, 05 Jan 2014 21:31:40 GMT")
+		return r
+	}
+	tests := []struct {
+		name        string
+		policy      Policy
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:        "Empty policy",
+			policy:      Policy{},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Algorithm not allowed",
+			policy:      Policy{SignatureAlgorithms: []string{algoHmacSha512}},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "algorithm 'RSA-SHA256' not allowed by policy",
+		},
+		{
+			name:        "RSA key is too short",
+			policy:      NewStrictPolicy(),
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "key size 1024 bits for keyID 'Test' is less than 2048 bits",
+		},
+		{
+			name:        "RSA key size is enough",
+			policy:      Policy{MinRSAKeySize: 1024},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(ss)
+			hs.SetPolicy(tt.policy)
+			err := hs.VerifySignature(getRequest())
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestVerifyMessageSignatureRequiredComponents(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      Policy
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name:        "Components covered",
+			policy:      Policy{RequiredComponents: []string{"content-type", "date"}},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name:        "Component not covered",
+			policy:      Policy{RequiredComponents: []string{"date", "@method"}},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  `component "@method" required by policy is not covered by signature 'sig1'`,
+		},
+		{
+			name:        "Component parameters differ",
+			policy:      Policy{RequiredComponents: []string{"date;sf"}},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  `component "date";sf required by policy is not covered by signature 'sig1'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			hs.SetPolicy(tt.policy)
+			r := getMessageSignatureRequestFunc()
+			r.Header.Set("Signature-Input", `sig1=("date" "content-type");created=1618884473;keyid="test-shared-secret"`)
+			r.Header.Set("Signature", `sig1=:tvqgXRD5g3mzNmVnYBuxtg5V8PYDi+c9QloHuKjuQQg=:`)
+			err := hs.VerifyMessageSignature(r)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestVerifyRequiredParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		sign        func(hs *HTTPSignatures, r *http.Request)
+		policy      Policy
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "RFC 9421 params set",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				s, _ := messageSignaturesSecrets.Get("test-shared-secret")
+				_ = hs.SignMessage(s, r, MessageSignatureOptions{Nonce: "n-1", Tag: "app"})
+			},
+			policy:      Policy{RequiredParams: []string{"nonce", "tag"}},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "RFC 9421 param not set",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				s, _ := messageSignaturesSecrets.Get("test-shared-secret")
+				_ = hs.SignMessage(s, r, MessageSignatureOptions{Tag: "app"})
+			},
+			policy:      Policy{RequiredParams: []string{"nonce"}},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "param 'nonce' required by policy not found",
+		},
+		{
+			name: "Cavage params set",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				s, _ := messageSignaturesSecrets.Get("test-shared-secret")
+				_ = hs.AddSignature(s, r)
+				r.Header.Set("Signature", r.Header.Get("Signature")+`,nonce="n-1",tag="app"`)
+			},
+			policy:      Policy{RequiredParams: []string{"nonce", "tag"}},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Cavage param not set",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				s, _ := messageSignaturesSecrets.Get("test-shared-secret")
+				_ = hs.AddSignature(s, r)
+				r.Header.Set("Signature", r.Header.Get("Signature")+`,tag="app"`)
+			},
+			policy:      Policy{RequiredParams: []string{"nonce"}},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "param 'nonce' required by policy not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			r := getMessageSignatureRequestFunc()
+			tt.sign(hs, r)
+			hs.SetPolicy(tt.policy)
+			_, err := hs.Verify(r)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+			if err != nil && !errors.Is(err, ErrPolicyViolation) {
+				t.Errorf("%s: error is not ErrPolicyViolation: %v", tt.name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ResponseWriter buffering http.ResponseWriter: status & body are kept in memory until Close,
+// so digest & signature headers could be added before the headers are flushed
+type ResponseWriter struct {
+	http.ResponseWriter
+	r       *http.Request
+	sign    func(m message) error
+	status  int
+	body    bytes.Buffer
+	flushed bool
+}
+
+// SignResponse wrap http.ResponseWriter to add Signature header to the response on Close.
+// Response headers to sign are set by SetDefaultResponseSignatureHeaders
+func (hs *HTTPSignatures) SignResponse(s Secret, w http.ResponseWriter, r *http.Request) *ResponseWriter {
+	return &ResponseWriter{
+		ResponseWriter: w,
+		r:              r,
+		sign: func(m message) error {
+			h, err := hs.createSignatureHeader(s, m)
+			if err != nil {
+				return err
+			}
+			m.header.Set(signatureHeader, h)
+			return nil
+		},
+	}
+}
+
+// SignMessageResponse wrap http.ResponseWriter to add RFC 9421 Signature-Input & Signature headers
+// to the response on Close
+func (hs *HTTPSignatures) SignMessageResponse(
+	s Secret, w http.ResponseWriter, r *http.Request, o MessageSignatureOptions,
+) *ResponseWriter {
+	return &ResponseWriter{
+		ResponseWriter: w,
+		r:              r,
+		sign: func(m message) error {
+			return hs.signMessage(s, m, o)
+		},
+	}
+}
+
+// WriteHeader buffer response status code
+func (w *ResponseWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+}
+
+// Write buffer response body
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Close sign the response, write status, headers & buffered body to the underlying http.ResponseWriter.
+// Nothing is written if signing fails, so an error response could be sent instead
+func (w *ResponseWriter) Close() error {
+	if w.flushed {
+		return nil
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	// Response is signed with a copy of the headers, signature & digest are added only if signing succeeds
+	header := w.Header().Clone()
+	var body io.ReadCloser = ioutil.NopCloser(bytes.NewReader(w.body.Bytes()))
+	m := message{header: header, body: &body, request: w.r, status: w.status}
+	if err := w.sign(m); err != nil {
+		return &Error{"error signing response", err}
+	}
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+
+	w.flushed = true
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.body.Bytes())
+	return err
+}
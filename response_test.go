@@ -0,0 +1,239 @@
+package httpsignatures
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerifyResponse(t *testing.T) {
+	secret, _ := messageSignaturesSecrets.Get("test-key-rsa")
+	tests := []struct {
+		name   string
+		sign   func(hs *HTTPSignatures, w http.ResponseWriter, r *http.Request) *ResponseWriter
+		verify func(hs *HTTPSignatures, resp *http.Response) error
+	}{
+		{
+			name: "Signature header",
+			sign: func(hs *HTTPSignatures, w http.ResponseWriter, r *http.Request) *ResponseWriter {
+				return hs.SignResponse(secret, w, r)
+			},
+			verify: func(hs *HTTPSignatures, resp *http.Response) error {
+				return hs.VerifyResponse(resp)
+			},
+		},
+		{
+			name: "RFC 9421 signature",
+			sign: func(hs *HTTPSignatures, w http.ResponseWriter, r *http.Request) *ResponseWriter {
+				return hs.SignMessageResponse(secret, w, r, MessageSignatureOptions{
+					Components: []string{"@status", "content-type"},
+					Expires:    time.Now().Add(time.Minute),
+				})
+			},
+			verify: func(hs *HTTPSignatures, resp *http.Response) error {
+				return hs.VerifyMessageResponse(resp)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sw := tt.sign(hs, w, r)
+				sw.Header().Set("Content-Type", "application/json")
+				sw.WriteHeader(http.StatusCreated)
+				_, _ = sw.Write([]byte(httpsignaturesBodyExample))
+				if err := sw.Close(); err != nil {
+					t.Errorf("sign error: %s", err)
+				}
+			}))
+			defer srv.Close()
+
+			resp, err := srv.Client().Get(srv.URL)
+			if err != nil {
+				t.Fatalf("request error: %s", err)
+			}
+			defer resp.Body.Close()
+			if err = tt.verify(hs, resp); err != nil {
+				t.Errorf("verify error: %s", err)
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			if string(body) != httpsignaturesBodyExample {
+				t.Errorf("body = %s, want %s", body, httpsignaturesBodyExample)
+			}
+		})
+	}
+}
+
+func TestSignResponseError(t *testing.T) {
+	secret := Secret{KeyID: "wrong", Algorithm: "RSA-SHA256", PrivateKey: "wrong key"}
+	tests := []struct {
+		name string
+		sign func(hs *HTTPSignatures, w http.ResponseWriter, r *http.Request) *ResponseWriter
+	}{
+		{
+			name: "Signature header",
+			sign: func(hs *HTTPSignatures, w http.ResponseWriter, r *http.Request) *ResponseWriter {
+				return hs.SignResponse(secret, w, r)
+			},
+		},
+		{
+			name: "RFC 9421 signature",
+			sign: func(hs *HTTPSignatures, w http.ResponseWriter, r *http.Request) *ResponseWriter {
+				return hs.SignMessageResponse(secret, w, r, MessageSignatureOptions{Components: []string{"@status"}})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			rec := httptest.NewRecorder()
+			sw := tt.sign(hs, rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			sw.Header().Set("Content-Type", "application/json")
+			_, _ = sw.Write([]byte(httpsignaturesBodyExample))
+			err := sw.Close()
+			assert(t, false, err, "*httpsignatures.Error", tt.name, false,
+				"error signing response: error creating signature: CryptoError: no private key found")
+
+			// Nothing is written & headers are not changed, so an error response could be sent instead
+			if len(rec.Header()) != 1 || rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("headers changed: %v", rec.Header())
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("body written: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestVerifyResponse(t *testing.T) {
+	secret, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	type args struct {
+		status int
+		body   string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Valid signature",
+			args: args{
+				status: http.StatusOK,
+				body:   httpsignaturesBodyExample,
+			},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Wrong status",
+			args: args{
+				status: http.StatusNotFound,
+				body:   httpsignaturesBodyExample,
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong signature: CryptoError: wrong signature",
+		},
+		{
+			name: "Wrong body",
+			args: args{
+				status: http.StatusOK,
+				body:   `{"hello": "wrong"}`,
+			},
+			want:        false,
+			wantErrType: digestErrType,
+			wantErrMsg:  "DigestError: wrong digest: CryptoError: wrong hash",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			w := httptest.NewRecorder()
+			sw := hs.SignResponse(secret, w, nil)
+			_, _ = sw.Write([]byte(httpsignaturesBodyExample))
+			if err := sw.Close(); err != nil {
+				t.Fatalf("sign error: %s", err)
+			}
+
+			resp := w.Result()
+			resp.StatusCode = tt.args.status
+			resp.Body = ioutil.NopCloser(strings.NewReader(tt.args.body))
+			err := hs.VerifyResponse(resp)
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestResponseComponents(t *testing.T) {
+	secret, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	tests := []struct {
+		name        string
+		sign        func(hs *HTTPSignatures, w http.ResponseWriter) *ResponseWriter
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Request target",
+			sign: func(hs *HTTPSignatures, w http.ResponseWriter) *ResponseWriter {
+				hs.SetDefaultResponseSignatureHeaders([]string{"(request-target)"})
+				return hs.SignResponse(secret, w, nil)
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg: "error signing response: build signature string error: " +
+				"param '(request-target)' is not available for response",
+		},
+		{
+			name: "Request derived component",
+			sign: func(hs *HTTPSignatures, w http.ResponseWriter) *ResponseWriter {
+				return hs.SignMessageResponse(secret, w, nil, MessageSignatureOptions{Components: []string{"@method"}})
+			},
+			want:        false,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg: "error signing response: build signature base error: " +
+				"component '@method' is not available for response",
+		},
+		{
+			name: "Response header",
+			sign: func(hs *HTTPSignatures, w http.ResponseWriter) *ResponseWriter {
+				hs.SetDefaultResponseSignatureHeaders([]string{"(status)", "host"})
+				w.Header().Set("Host", "example.com")
+				return hs.SignResponse(secret, w, nil)
+			},
+			want:        true,
+			wantErrType: httpsignaturesErrType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			w := httptest.NewRecorder()
+			err := tt.sign(hs, w).Close()
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+			if got != (w.Code == http.StatusOK && len(w.Header().Get("Signature")) > 0) {
+				t.Errorf("response written = %v, want %v", !got, got)
+			}
+		})
+	}
+}
+
+func TestStatusComponentInRequest(t *testing.T) {
+	hs := NewHTTPSignatures(messageSignaturesSecrets)
+	ph := ParsedHeader{headers: []string{"(status)"}}
+	_, err := hs.buildSignatureString(ph, requestMessage(getMessageSignatureRequestFunc()))
+	assert(t, nil, err, httpsignaturesErrType, "(status)", nil, "param '(status)' is available for response only")
+
+	c, _ := hs.parseComponent("@status")
+	_, err = hs.componentValue(c, requestMessage(getMessageSignatureRequestFunc()))
+	assert(t, nil, err, httpsignaturesErrType, "@status", nil, "component '@status' is available for response only")
+}
@@ -20,7 +20,7 @@ func (a RsaSha256) Algorithm() string {
 	return algoRsaSha256
 }
 
-// Create Create signature using passed privateKey from secret
+// Create Create signature using passed privateKey from secret (PEM PKCS#1 or PKCS#8)
 func (a RsaSha256) Create(secret Secret, data []byte) ([]byte, error) {
 	block, _ := pem.Decode([]byte(secret.PrivateKey))
 	if block == nil {
@@ -35,6 +35,16 @@ func (a RsaSha256) Create(secret Secret, data []byte) ([]byte, error) {
 		if err != nil {
 			return nil, &CryptoError{"error ParsePKCS1PrivateKey", err}
 		}
+	case "PRIVATE KEY":
+		var key interface{}
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, &CryptoError{"error ParsePKCS8PrivateKey", err}
+		}
+		var ok bool
+		if privateKey, ok = key.(*rsa.PrivateKey); !ok {
+			return nil, &CryptoError{"unknown type of private key", nil}
+		}
 	default:
 		return nil, &CryptoError{fmt.Sprintf("unsupported key type %s", block.Type), err}
 	}
@@ -44,11 +54,34 @@ func (a RsaSha256) Create(secret Secret, data []byte) ([]byte, error) {
 	return rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, h.Sum(nil))
 }
 
-// Verify Verify signature using passed publicKey from secret
+// Verify Verify signature using passed publicKey from secret (PEM PKIX or PKCS#1)
 func (a RsaSha256) Verify(secret Secret, data []byte, signature []byte) error {
+	publicKey, err := a.publicKey(secret)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	_, _ = h.Write(data)
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, h.Sum(nil), signature); err != nil {
+		return &CryptoError{"error verify signature", err}
+	}
+	return nil
+}
+
+// KeySize Return size of the public key from secret in bits
+func (a RsaSha256) KeySize(secret Secret) (int, error) {
+	publicKey, err := a.publicKey(secret)
+	if err != nil {
+		return 0, err
+	}
+	return publicKey.N.BitLen(), nil
+}
+
+func (a RsaSha256) publicKey(secret Secret) (*rsa.PublicKey, *CryptoError) {
 	block, _ := pem.Decode([]byte(secret.PublicKey))
 	if block == nil {
-		return &CryptoError{"no public key found", nil}
+		return nil, &CryptoError{"no public key found", nil}
 	}
 
 	var pub interface{}
@@ -57,25 +90,21 @@ func (a RsaSha256) Verify(secret Secret, data []byte, signature []byte) error {
 	case "PUBLIC KEY":
 		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
 		if err != nil {
-			return &CryptoError{"error ParsePKIXPublicKey", err}
+			return nil, &CryptoError{"error ParsePKIXPublicKey", err}
+		}
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, &CryptoError{"error ParsePKCS1PublicKey", err}
 		}
 	default:
-		return &CryptoError{fmt.Sprintf("unsupported key type %s", block.Type), err}
+		return nil, &CryptoError{fmt.Sprintf("unsupported key type %s", block.Type), err}
 	}
 
-	var publicKey *rsa.PublicKey
 	switch pub := pub.(type) {
 	case *rsa.PublicKey:
-		publicKey = pub
+		return pub, nil
 	default:
-		return &CryptoError{"unknown type of public key", nil}
+		return nil, &CryptoError{"unknown type of public key", nil}
 	}
-
-	h := sha256.New()
-	_, _ = h.Write(data)
-	err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, h.Sum(nil), signature)
-	if err != nil {
-		return &CryptoError{"error verify signature", err}
-	}
-	return nil
 }
@@ -19,20 +19,28 @@ func (e *SecretError) Error() string {
 	return fmt.Sprintf("SecretError: %s", e.Message)
 }
 
+// Unwrap wrapped error
+func (e *SecretError) Unwrap() error {
+	return e.Err
+}
+
 // Secrets interface to retrieve secrets from storage (local, DB, file etc)
 type Secrets interface {
 	Get(keyID string) (Secret, error)
 }
 
 // Secret struct to return/store secret
+// Metadata optional application data of the key (owner, scopes etc) returned in VerificationResult
 type Secret struct {
 	KeyID      string
 	PublicKey  string
 	PrivateKey string
 	Algorithm  string
+	Metadata   map[string]string
 }
 
 // SecretsStorage local static secrets storage
+// Storage is read only after creation, so it's safe for concurrent use by multiple goroutines
 type SecretsStorage struct {
 	storage map[string]Secret
 }
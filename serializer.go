@@ -0,0 +1,188 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewParsedHeader create header from params, e.g. to serialize it with SerializeSignatureHeader.
+// Zero created & expires are not set, headers default to `(created)` as for the parsed header without headers param
+func NewParsedHeader(
+	keyID, algorithm string, createdAt, expiresAt time.Time, headers []string, signature string,
+) ParsedHeader {
+	ph := ParsedHeader{
+		keyID:     keyID,
+		algorithm: algorithm,
+		created:   createdAt,
+		expires:   expiresAt,
+		headers:   append([]string(nil), headers...),
+		signature: signature,
+	}
+	if len(ph.headers) == 0 {
+		ph.headers = []string{created}
+	}
+	return ph
+}
+
+// WithParam copy of the header with extension param set, e.g. `nonce`
+func (ph ParsedHeader) WithParam(name, value string) ParsedHeader {
+	params := ph.Params()
+	params[name] = value
+	ph.params = params
+	return ph
+}
+
+// SerializeSignatureHeader create canonical Signature header value from the parsed header: params are sorted
+// in the draft order (keyId, algorithm, created, expires, headers, signature) followed by extension params
+// sorted by name, string values are quoted & escaped, empty values & zero created/expires are omitted.
+// Header is guaranteed to be parsed into the same params by the strict Parser
+func SerializeSignatureHeader(ph ParsedHeader) (string, error) {
+	var b strings.Builder
+	write := func(name, value string) {
+		if b.Len() > 0 {
+			b.WriteByte(div)
+		}
+		b.WriteString(name)
+		b.WriteByte(equal)
+		b.WriteString(value)
+	}
+	writeString := func(name, value string) error {
+		if len(value) == 0 {
+			return nil
+		}
+		if hasControlChars(value) {
+			return &Error{fmt.Sprintf("param '%s' value must not contain control characters", name), nil}
+		}
+		write(name, quotedString(value))
+		return nil
+	}
+
+	if err := writeString(knownParams[paramKeyID], ph.keyID); err != nil {
+		return "", err
+	}
+	if err := writeString(knownParams[paramAlgorithm], ph.algorithm); err != nil {
+		return "", err
+	}
+	if !ph.created.IsZero() {
+		// 2.1.4 created MUST be a Unix timestamp integer value
+		if ph.created.Nanosecond() != 0 {
+			return "", &Error{"param 'created' must be an integer Unix timestamp", nil}
+		}
+		if err := checkTimestamp(knownParams[paramCreated], ph.created); err != nil {
+			return "", err
+		}
+		write(knownParams[paramCreated], formatTimestamp(ph.created))
+	}
+	if !ph.expires.IsZero() {
+		if err := checkTimestamp(knownParams[paramExpires], ph.expires); err != nil {
+			return "", err
+		}
+		write(knownParams[paramExpires], formatTimestamp(ph.expires))
+	}
+	for _, h := range ph.headers {
+		if len(h) == 0 || strings.IndexAny(h, " \t") != -1 {
+			return "", &Error{fmt.Sprintf("wrong header name '%s'", h), nil}
+		}
+	}
+	if err := writeString(knownParams[paramHeaders], strings.Join(ph.headers, " ")); err != nil {
+		return "", err
+	}
+	if err := writeString(knownParams[paramSignature], ph.signature); err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(ph.params))
+	for k := range ph.params {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if !isToken(k) || isKnownParam(k) {
+			return "", &Error{fmt.Sprintf("wrong param name '%s'", k), nil}
+		}
+		if err := writeString(k, ph.params[k]); err != nil {
+			return "", err
+		}
+	}
+
+	return b.String(), nil
+}
+
+// SerializeAuthorizationHeader create canonical Authorization header value (`Signature` scheme & params)
+// from the parsed header, see SerializeSignatureHeader
+func SerializeAuthorizationHeader(ph ParsedHeader) (string, error) {
+	h, err := SerializeSignatureHeader(ph)
+	if err != nil {
+		return "", err
+	}
+	return authorizationScheme + " " + h, nil
+}
+
+// quotedString quote param value, '"' & '\' symbols are escaped with backslash (quoted-pair)
+func quotedString(s string) string {
+	if strings.IndexAny(s, `"\`) == -1 {
+		return `"` + s + `"`
+	}
+	var b strings.Builder
+	b.Grow(len(s) + 4)
+	b.WriteByte(quote)
+	for i := 0; i < len(s); i++ {
+		if s[i] == quote || s[i] == bsl {
+			b.WriteByte(bsl)
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte(quote)
+	return b.String()
+}
+
+// formatTimestamp Unix time with fraction of second if any (`1402170699.5`), trailing zeros are omitted
+func formatTimestamp(t time.Time) string {
+	s := strconv.FormatInt(t.Unix(), 10)
+	if ns := t.Nanosecond(); ns > 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%09d", ns), "0")
+	}
+	return s
+}
+
+// checkTimestamp created & expires must be in the range accepted by the parser: from Unix epoch to maxTimestamp
+func checkTimestamp(name string, t time.Time) error {
+	if sec := t.Unix(); sec < 0 || sec > maxTimestamp {
+		return &Error{fmt.Sprintf("param '%s' is out of range 0..%d", name, int64(maxTimestamp)), nil}
+	}
+	return nil
+}
+
+func hasControlChars(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if isControlChar(s[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isToken(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isTokenChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isKnownParam param defined by the draft (case-insensitive, as matched by the lenient parser)
+func isKnownParam(name string) bool {
+	for _, k := range knownParams {
+		if strings.EqualFold(name, k) {
+			return true
+		}
+	}
+	return false
+}
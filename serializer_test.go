@@ -0,0 +1,194 @@
+package httpsignatures
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewParsedHeader(t *testing.T) {
+	headers := []string{"(request-target)", "date"}
+	ph := NewParsedHeader("v1", "hs2019", time.Unix(1402170695, 0), time.Time{}, headers, "v2")
+	headers[0] = "changed"
+	want := ParsedHeader{
+		keyID:     "v1",
+		algorithm: "hs2019",
+		created:   time.Unix(1402170695, 0),
+		headers:   []string{"(request-target)", "date"},
+		signature: "v2",
+	}
+	assert(t, ph, nil, "", "Headers are copied", want, "")
+
+	ph = NewParsedHeader("v1", "", time.Time{}, time.Time{}, nil, "v2")
+	assert(t, ph.Headers(), nil, "", "Default headers", validHeadersIfNotSpecified, "")
+
+	withNonce := ph.WithParam("nonce", "n-1")
+	withTag := withNonce.WithParam("tag", "app")
+	assert(t, withNonce.Params(), nil, "", "Param set", map[string]string{"nonce": "n-1"}, "")
+	assert(t, withTag.Params(), nil, "", "Params are copied", map[string]string{"nonce": "n-1", "tag": "app"}, "")
+	assert(t, len(ph.Params()), nil, "", "Original header not changed", 0, "")
+}
+
+func TestSerializeSignatureHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		ph          ParsedHeader
+		want        string
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "All params",
+			ph:   validParsedSignatureHeader,
+			want: validSignatureHeader,
+		},
+		{
+			name: "Params order & extension params",
+			ph: NewParsedHeader("v1", "hs2019", time.Unix(1402170695, 0), time.Unix(1402170699, 0), nil, "v2").
+				WithParam("tag", "app").
+				WithParam("nonce", "n-1"),
+			want: `keyId="v1",algorithm="hs2019",created=1402170695,expires=1402170699,headers="(created)",signature="v2",nonce="n-1",tag="app"`,
+		},
+		{
+			name: "Empty values omitted",
+			ph:   NewParsedHeader("v1", "", time.Time{}, time.Time{}, nil, ""),
+			want: `keyId="v1",headers="(created)"`,
+		},
+		{
+			name: "Escaped values",
+			ph:   NewParsedHeader(`key "1"`, "", time.Time{}, time.Time{}, nil, `a\b`),
+			want: `keyId="key \"1\"",headers="(created)",signature="a\\b"`,
+		},
+		{
+			name: "Decimal expires",
+			ph:   NewParsedHeader("v1", "", time.Time{}, time.Unix(1402170699, 500000000), []string{"(expires)"}, ""),
+			want: `keyId="v1",expires=1402170699.5,headers="(expires)"`,
+		},
+		{
+			name: "Unix epoch created",
+			ph:   NewParsedHeader("v1", "", time.Unix(0, 0), time.Time{}, nil, ""),
+			want: `keyId="v1",created=0,headers="(created)"`,
+		},
+		{
+			name:        "Decimal created",
+			ph:          NewParsedHeader("v1", "", time.Unix(1402170695, 500000000), time.Time{}, nil, ""),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "param 'created' must be an integer Unix timestamp",
+		},
+		{
+			name:        "Negative created",
+			ph:          NewParsedHeader("v1", "", time.Unix(-1, 0), time.Time{}, nil, ""),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "param 'created' is out of range 0..253402300799",
+		},
+		{
+			name:        "Negative decimal expires",
+			ph:          NewParsedHeader("v1", "", time.Time{}, time.Unix(-1, 500000000), []string{"(expires)"}, ""),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "param 'expires' is out of range 0..253402300799",
+		},
+		{
+			name:        "Expires after max timestamp",
+			ph:          NewParsedHeader("v1", "", time.Time{}, time.Unix(253402300800, 0), []string{"(expires)"}, ""),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "param 'expires' is out of range 0..253402300799",
+		},
+		{
+			name: "Max timestamp",
+			ph:   NewParsedHeader("v1", "", time.Unix(253402300799, 0), time.Time{}, nil, ""),
+			want: `keyId="v1",created=253402300799,headers="(created)"`,
+		},
+		{
+			name:        "Control characters in value",
+			ph:          NewParsedHeader("v\n1", "", time.Time{}, time.Time{}, nil, ""),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "param 'keyId' value must not contain control characters",
+		},
+		{
+			name:        "Control characters in extension param",
+			ph:          NewParsedHeader("v1", "", time.Time{}, time.Time{}, nil, "").WithParam("nonce", "\x00"),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "param 'nonce' value must not contain control characters",
+		},
+		{
+			name:        "Space in header name",
+			ph:          NewParsedHeader("v1", "", time.Time{}, time.Time{}, []string{"date", "x header"}, ""),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong header name 'x header'",
+		},
+		{
+			name:        "Empty header name",
+			ph:          NewParsedHeader("v1", "", time.Time{}, time.Time{}, []string{"date", ""}, ""),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong header name ''",
+		},
+		{
+			name:        "Wrong extension param name",
+			ph:          NewParsedHeader("v1", "", time.Time{}, time.Time{}, nil, "").WithParam("x tag", "v2"),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong param name 'x tag'",
+		},
+		{
+			name:        "Extension param with name of known param",
+			ph:          NewParsedHeader("v1", "", time.Time{}, time.Time{}, nil, "").WithParam("KeyID", "v2"),
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong param name 'KeyID'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SerializeSignatureHeader(tt.ph)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+			if err != nil {
+				return
+			}
+			ph, pErr := NewStrictParser().ParseSignatureHeader(got)
+			if pErr != nil || !reflect.DeepEqual(ph, tt.ph) {
+				t.Errorf("%s: parsed header %v (%v), want %v", tt.name, ph, pErr, tt.ph)
+			}
+		})
+	}
+}
+
+func TestSerializeAuthorizationHeader(t *testing.T) {
+	got, err := SerializeAuthorizationHeader(validParsedSignatureHeader)
+	assert(t, got, err, httpsignaturesErrType, "Valid header", validAuthorizationHeader, "")
+
+	_, err = SerializeAuthorizationHeader(NewParsedHeader("v\n1", "", time.Time{}, time.Time{}, nil, ""))
+	assert(t, err != nil, err, httpsignaturesErrType, "Wrong header", true,
+		"param 'keyId' value must not contain control characters")
+}
+
+func TestQuotedString(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  string
+		want string
+	}{
+		{
+			name: "Plain value",
+			arg:  "test-key",
+			want: `"test-key"`,
+		},
+		{
+			name: "Empty value",
+			arg:  "",
+			want: `""`,
+		},
+		{
+			name: "Quotes & backslashes",
+			arg:  `key "1"\2`,
+			want: `"key \"1\"\\2"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := quotedString(tt.arg)
+			assert(t, got, nil, "", tt.name, tt.want, "")
+			ph, err := NewStrictParser().ParseSignatureHeader("keyId=" + got)
+			if len(tt.arg) > 0 && (err != nil || ph.KeyID() != tt.arg) {
+				t.Errorf("%s: parsed keyId %q (%v), want %q", tt.name, ph.KeyID(), err, tt.arg)
+			}
+		})
+	}
+}
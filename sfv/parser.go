@@ -0,0 +1,381 @@
+package sfv
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxIntegerLength  = 15
+	maxDecimalInteger = 12
+	maxDecimalLength  = 16
+	maxDecimalFrac    = 3
+)
+
+type parser struct {
+	s   string
+	pos int
+}
+
+// ParseDictionary parse Dictionary header value (4.2.2)
+func ParseDictionary(s string) (Dictionary, error) {
+	p := newParser(s)
+	d := Dictionary{}
+	for !p.eof() {
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		var m Member
+		if p.peek() == '=' {
+			p.pos++
+			if m, err = p.parseItemOrInnerList(); err != nil {
+				return nil, err
+			}
+		} else {
+			params, err := p.parseParameters()
+			if err != nil {
+				return nil, err
+			}
+			m = Item{true, params}
+		}
+		d.Set(key, m)
+		if err = p.parseMemberDiv(); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// ParseList parse List header value (4.2.1)
+func ParseList(s string) (List, error) {
+	p := newParser(s)
+	l := List{}
+	for !p.eof() {
+		m, err := p.parseItemOrInnerList()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, m)
+		if err = p.parseMemberDiv(); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// ParseItem parse Item header value (4.2.3)
+func ParseItem(s string) (Item, error) {
+	p := newParser(s)
+	i, err := p.parseItem()
+	if err != nil {
+		return Item{}, err
+	}
+	if !p.eof() {
+		return Item{}, p.errorf("unexpected symbol '%c' after item", p.peek())
+	}
+	return i, nil
+}
+
+// newParser discard leading & trailing SP characters (4.2)
+func newParser(s string) *parser {
+	return &parser{s: strings.Trim(s, " ")}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *parser) errorf(format string, a ...interface{}) *Error {
+	return &Error{fmt.Sprintf(format, a...) + fmt.Sprintf(" at position %d", p.pos), nil}
+}
+
+func (p *parser) skipOWS() {
+	for !p.eof() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) skipSP() {
+	for !p.eof() && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) parseMemberDiv() error {
+	p.skipOWS()
+	if p.eof() {
+		return nil
+	}
+	if p.s[p.pos] != ',' {
+		return p.errorf("unexpected symbol '%c', expected ','", p.s[p.pos])
+	}
+	p.pos++
+	p.skipOWS()
+	if p.eof() {
+		return p.errorf("unexpected end of value after ','")
+	}
+	return nil
+}
+
+func (p *parser) parseItemOrInnerList() (Member, error) {
+	if p.peek() == '(' {
+		return p.parseInnerList()
+	}
+	return p.parseItem()
+}
+
+func (p *parser) parseInnerList() (InnerList, error) {
+	p.pos++
+	l := InnerList{Items: []Item{}}
+	for !p.eof() {
+		p.skipSP()
+		if p.peek() == ')' {
+			p.pos++
+			params, err := p.parseParameters()
+			if err != nil {
+				return InnerList{}, err
+			}
+			l.Params = params
+			return l, nil
+		}
+		i, err := p.parseItem()
+		if err != nil {
+			return InnerList{}, err
+		}
+		l.Items = append(l.Items, i)
+		if c := p.peek(); !p.eof() && c != ' ' && c != ')' {
+			return InnerList{}, p.errorf("unexpected symbol in inner list, expected ' ' or ')'")
+		}
+	}
+	return InnerList{}, p.errorf("unexpected end of inner list")
+}
+
+func (p *parser) parseItem() (Item, error) {
+	v, err := p.parseBareItem()
+	if err != nil {
+		return Item{}, err
+	}
+	params, err := p.parseParameters()
+	if err != nil {
+		return Item{}, err
+	}
+	return Item{v, params}, nil
+}
+
+func (p *parser) parseParameters() (Params, error) {
+	params := Params{}
+	for p.peek() == ';' {
+		p.pos++
+		p.skipSP()
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		var v interface{} = true
+		if p.peek() == '=' {
+			p.pos++
+			if v, err = p.parseBareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params.Set(key, v)
+	}
+	return params, nil
+}
+
+func (p *parser) parseKey() (string, error) {
+	c := p.peek()
+	if !isLcAlpha(c) && c != '*' {
+		return "", p.errorf("key must start from lowercase letter or '*'")
+	}
+	start := p.pos
+	for !p.eof() && isKeyChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos], nil
+}
+
+func (p *parser) parseBareItem() (interface{}, error) {
+	c := p.peek()
+	switch {
+	case c == '-' || isDigit(c):
+		return p.parseNumber()
+	case c == '"':
+		return p.parseString()
+	case c == '*' || isAlpha(c):
+		return p.parseToken()
+	case c == ':':
+		return p.parseByteSequence()
+	case c == '?':
+		return p.parseBoolean()
+	case p.eof():
+		return nil, p.errorf("unexpected end of value, expected item")
+	default:
+		return nil, p.errorf("unexpected symbol '%c', expected item", c)
+	}
+}
+
+func (p *parser) parseNumber() (interface{}, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	if !isDigit(p.peek()) {
+		return nil, p.errorf("number must contain digits")
+	}
+	digits := p.pos
+	decimal := false
+	for !p.eof() {
+		c := p.s[p.pos]
+		if isDigit(c) {
+			p.pos++
+		} else if !decimal && c == '.' {
+			if p.pos-digits > maxDecimalInteger {
+				return nil, p.errorf("too many digits in integer part of decimal")
+			}
+			decimal = true
+			p.pos++
+		} else {
+			break
+		}
+		if !decimal && p.pos-digits > maxIntegerLength {
+			return nil, p.errorf("too many digits in integer")
+		}
+		if decimal && p.pos-digits > maxDecimalLength {
+			return nil, p.errorf("too many digits in decimal")
+		}
+	}
+
+	n := p.s[start:p.pos]
+	if !decimal {
+		i, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			return nil, &Error{"wrong integer", err}
+		}
+		return i, nil
+	}
+	if n[len(n)-1] == '.' {
+		return nil, p.errorf("decimal must not end with '.'")
+	}
+	if len(n)-strings.IndexByte(n, '.')-1 > maxDecimalFrac {
+		return nil, p.errorf("too many digits in fractional part of decimal")
+	}
+	f, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return nil, &Error{"wrong decimal", err}
+	}
+	return f, nil
+}
+
+func (p *parser) parseString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for !p.eof() {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == '\\':
+			if p.eof() {
+				return "", p.errorf("unexpected end of string after '\\'")
+			}
+			next := p.s[p.pos]
+			if next != '"' && next != '\\' {
+				return "", p.errorf("wrong escape sequence '\\%c' in string", next)
+			}
+			b.WriteByte(next)
+			p.pos++
+		case c == '"':
+			return b.String(), nil
+		case c < 0x20 || c > 0x7e:
+			return "", p.errorf("unsupported symbol 0x%02x in string", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unexpected end of string, expected '\"'")
+}
+
+func (p *parser) parseToken() (Token, error) {
+	start := p.pos
+	p.pos++
+	for !p.eof() && (isTChar(p.s[p.pos]) || p.s[p.pos] == ':' || p.s[p.pos] == '/') {
+		p.pos++
+	}
+	return Token(p.s[start:p.pos]), nil
+}
+
+func (p *parser) parseByteSequence() ([]byte, error) {
+	p.pos++
+	end := strings.IndexByte(p.s[p.pos:], ':')
+	if end == -1 {
+		return nil, p.errorf("unexpected end of byte sequence, expected ':'")
+	}
+	v := p.s[p.pos : p.pos+end]
+	for i := 0; i < len(v); i++ {
+		if !isBase64Char(v[i]) {
+			p.pos += i
+			return nil, p.errorf("unsupported symbol '%c' in byte sequence", v[i])
+		}
+	}
+	p.pos += end + 1
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		// 4.2.7 parsers SHOULD NOT fail when "=" padding is not present
+		if b, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(v, "=")); err != nil {
+			return nil, &Error{"wrong byte sequence", err}
+		}
+	}
+	return b, nil
+}
+
+func (p *parser) parseBoolean() (bool, error) {
+	p.pos++
+	switch p.peek() {
+	case '1':
+		p.pos++
+		return true, nil
+	case '0':
+		p.pos++
+		return false, nil
+	default:
+		return false, p.errorf("wrong boolean, expected '?1' or '?0'")
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLcAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+func isAlpha(c byte) bool {
+	return isLcAlpha(c) || (c >= 'A' && c <= 'Z')
+}
+
+func isKeyChar(c byte) bool {
+	return isLcAlpha(c) || isDigit(c) || c == '_' || c == '-' || c == '.' || c == '*'
+}
+
+// isTChar token characters (RFC 7230 3.2.6)
+func isTChar(c byte) bool {
+	if isAlpha(c) || isDigit(c) {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) != -1
+}
+
+func isBase64Char(c byte) bool {
+	return isAlpha(c) || isDigit(c) || c == '+' || c == '/' || c == '='
+}
@@ -0,0 +1,248 @@
+package sfv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDictionary(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		want       Dictionary
+		wantErrMsg string
+	}{
+		{
+			name:   "Signature-Input",
+			header: `sig1=("@method" "content-type");created=1618884473;keyid="test-key"`,
+			want: Dictionary{
+				{
+					Key: "sig1",
+					Value: InnerList{
+						Items: []Item{
+							{Value: "@method", Params: Params{}},
+							{Value: "content-type", Params: Params{}},
+						},
+						Params: Params{{"created", int64(1618884473)}, {"keyid", "test-key"}},
+					},
+				},
+			},
+		},
+		{
+			name:   "Signature",
+			header: `sig1=:aGVsbG8=:, sig2=:d29ybGQ=:`,
+			want: Dictionary{
+				{Key: "sig1", Value: Item{Value: []byte("hello"), Params: Params{}}},
+				{Key: "sig2", Value: Item{Value: []byte("world"), Params: Params{}}},
+			},
+		},
+		{
+			name:   "Boolean members & duplicate keys",
+			header: `a, b;x=?0, a=1.5`,
+			want: Dictionary{
+				{Key: "a", Value: Item{Value: 1.5, Params: Params{}}},
+				{Key: "b", Value: Item{Value: true, Params: Params{{"x", false}}}},
+			},
+		},
+		{
+			name:   "Empty",
+			header: ``,
+			want:   Dictionary{},
+		},
+		{
+			name:       "Trailing comma",
+			header:     `a=1,`,
+			wantErrMsg: "StructuredFieldError: unexpected end of value after ',' at position 4",
+		},
+		{
+			name:       "Wrong key",
+			header:     `A=1`,
+			wantErrMsg: "StructuredFieldError: key must start from lowercase letter or '*' at position 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDictionary(tt.header)
+			if err != nil && err.Error() != tt.wantErrMsg {
+				t.Errorf("error message = `%s`, wantErrMsg = `%s`", err.Error(), tt.wantErrMsg)
+			}
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf("expected error `%s`", tt.wantErrMsg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  = %#v,\nwant = %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseItem(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		want       Item
+		wantErrMsg string
+	}{
+		{name: "Integer", header: `42`, want: Item{int64(42), Params{}}},
+		{name: "Negative integer", header: `-42`, want: Item{int64(-42), Params{}}},
+		{name: "Leading zeros", header: `0042`, want: Item{int64(42), Params{}}},
+		{name: "Max integer", header: `999999999999999`, want: Item{int64(999999999999999), Params{}}},
+		{name: "Too long integer", header: `1000000000000000`, wantErrMsg: "StructuredFieldError: too many digits in integer at position 16"},
+		{name: "Minus without digits", header: `-a`, wantErrMsg: "StructuredFieldError: number must contain digits at position 1"},
+		{name: "Decimal", header: `1.5`, want: Item{1.5, Params{}}},
+		{name: "Negative decimal", header: `-0.125`, want: Item{-0.125, Params{}}},
+		{name: "Max decimal", header: `999999999999.999`, want: Item{999999999999.999, Params{}}},
+		{name: "Too long integer part of decimal", header: `1234567890123.1`, wantErrMsg: "StructuredFieldError: too many digits in integer part of decimal at position 13"},
+		{name: "Too long fractional part", header: `1.1234`, wantErrMsg: "StructuredFieldError: too many digits in fractional part of decimal at position 6"},
+		{name: "Decimal ends with dot", header: `1.`, wantErrMsg: "StructuredFieldError: decimal must not end with '.' at position 2"},
+		{name: "String", header: `"hello world"`, want: Item{"hello world", Params{}}},
+		{name: "Empty string", header: `""`, want: Item{"", Params{}}},
+		{name: "String escapes", header: `"a \"b\" \\c"`, want: Item{`a "b" \c`, Params{}}},
+		{name: "Wrong string escape", header: `"a\b"`, wantErrMsg: "StructuredFieldError: wrong escape sequence '\\b' in string at position 3"},
+		{name: "Unterminated string", header: `"abc`, wantErrMsg: "StructuredFieldError: unexpected end of string, expected '\"' at position 4"},
+		{name: "Non ASCII string", header: "\"caf\xc3\xa9\"", wantErrMsg: "StructuredFieldError: unsupported symbol 0xc3 in string at position 5"},
+		{name: "Token", header: `foo123/456`, want: Item{Token("foo123/456"), Params{}}},
+		{name: "Token with star", header: `*foo:bar`, want: Item{Token("*foo:bar"), Params{}}},
+		{name: "Uppercase token", header: `Text/HTML`, want: Item{Token("Text/HTML"), Params{}}},
+		{name: "Byte sequence", header: `:aGVsbG8=:`, want: Item{[]byte("hello"), Params{}}},
+		{name: "Byte sequence without padding", header: `:aGVsbG8:`, want: Item{[]byte("hello"), Params{}}},
+		{name: "Empty byte sequence", header: `::`, want: Item{[]byte{}, Params{}}},
+		{name: "Wrong byte sequence", header: `:aGVs*G8=:`, wantErrMsg: "StructuredFieldError: unsupported symbol '*' in byte sequence at position 5"},
+		{name: "Unterminated byte sequence", header: `:aGVsbG8=`, wantErrMsg: "StructuredFieldError: unexpected end of byte sequence, expected ':' at position 1"},
+		{name: "Boolean true", header: `?1`, want: Item{true, Params{}}},
+		{name: "Boolean false", header: `?0`, want: Item{false, Params{}}},
+		{name: "Wrong boolean", header: `?2`, wantErrMsg: "StructuredFieldError: wrong boolean, expected '?1' or '?0' at position 1"},
+		{
+			name:   "Parameters",
+			header: `abc;a=1;b=2.5;c="s";d=tok;e=:aGk=:;f;g=?0`,
+			want: Item{Token("abc"), Params{
+				{"a", int64(1)}, {"b", 2.5}, {"c", "s"}, {"d", Token("tok")}, {"e", []byte("hi")}, {"f", true}, {"g", false},
+			}},
+		},
+		{name: "Duplicate parameters", header: `1;a=1;b=2;a=3`, want: Item{int64(1), Params{{"a", int64(3)}, {"b", int64(2)}}}},
+		{name: "Space after semicolon", header: `1; a=1`, want: Item{int64(1), Params{{"a", int64(1)}}}},
+		{name: "Wrong parameter key", header: `1;A=1`, wantErrMsg: "StructuredFieldError: key must start from lowercase letter or '*' at position 2"},
+		{name: "Leading & trailing spaces", header: `  1  `, want: Item{int64(1), Params{}}},
+		{name: "Trailing garbage", header: `1 2`, wantErrMsg: "StructuredFieldError: unexpected symbol ' ' after item at position 1"},
+		{name: "Empty", header: ``, wantErrMsg: "StructuredFieldError: unexpected end of value, expected item at position 0"},
+		{name: "Unknown item", header: `@abc`, wantErrMsg: "StructuredFieldError: unexpected symbol '@', expected item at position 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseItem(tt.header)
+			if err != nil && err.Error() != tt.wantErrMsg {
+				t.Errorf("error message = `%s`, wantErrMsg = `%s`", err.Error(), tt.wantErrMsg)
+			}
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf("expected error `%s`", tt.wantErrMsg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  = %#v,\nwant = %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		want       List
+		wantErrMsg string
+	}{
+		{
+			name:   "Tokens",
+			header: `sugar, tea, rum`,
+			want:   List{Item{Token("sugar"), Params{}}, Item{Token("tea"), Params{}}, Item{Token("rum"), Params{}}},
+		},
+		{
+			name:   "Tabs & spaces between members",
+			header: "1 \t,\t 2",
+			want:   List{Item{int64(1), Params{}}, Item{int64(2), Params{}}},
+		},
+		{
+			name:   "Inner lists",
+			header: `("foo" "bar");lvl=5, ("baz";a=1), ()`,
+			want: List{
+				InnerList{[]Item{{"foo", Params{}}, {"bar", Params{}}}, Params{{"lvl", int64(5)}}},
+				InnerList{[]Item{{"baz", Params{{"a", int64(1)}}}}, Params{}},
+				InnerList{[]Item{}, Params{}},
+			},
+		},
+		{
+			name:   "Inner list with extra spaces",
+			header: `(  a   b  )`,
+			want:   List{InnerList{[]Item{{Token("a"), Params{}}, {Token("b"), Params{}}}, Params{}}},
+		},
+		{
+			name:   "Empty",
+			header: ``,
+			want:   List{},
+		},
+		{
+			name:       "Unterminated inner list",
+			header:     `(a b`,
+			wantErrMsg: "StructuredFieldError: unexpected end of inner list at position 4",
+		},
+		{
+			name:       "Wrong inner list delimiter",
+			header:     `(a,b)`,
+			wantErrMsg: "StructuredFieldError: unexpected symbol in inner list, expected ' ' or ')' at position 2",
+		},
+		{
+			name:       "Missing comma",
+			header:     `a b`,
+			wantErrMsg: "StructuredFieldError: unexpected symbol 'b', expected ',' at position 2",
+		},
+		{
+			name:       "Trailing comma",
+			header:     `a, b,`,
+			wantErrMsg: "StructuredFieldError: unexpected end of value after ',' at position 5",
+		},
+		{
+			name:       "Leading comma",
+			header:     `,a`,
+			wantErrMsg: "StructuredFieldError: unexpected symbol ',', expected item at position 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseList(tt.header)
+			if err != nil && err.Error() != tt.wantErrMsg {
+				t.Errorf("error message = `%s`, wantErrMsg = `%s`", err.Error(), tt.wantErrMsg)
+			}
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf("expected error `%s`", tt.wantErrMsg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  = %#v,\nwant = %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		t          FieldType
+		header     string
+		want       interface{}
+		wantErrMsg string
+	}{
+		{name: "Item", t: ItemType, header: `1`, want: Item{int64(1), Params{}}},
+		{name: "List", t: ListType, header: `1`, want: List{Item{int64(1), Params{}}}},
+		{name: "Dictionary", t: DictionaryType, header: `a=1`, want: Dictionary{{"a", Item{int64(1), Params{}}}}},
+		{name: "Unknown type", t: FieldType(10), header: `1`, wantErrMsg: "StructuredFieldError: unknown field type 10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.t, tt.header)
+			if err != nil && err.Error() != tt.wantErrMsg {
+				t.Errorf("error message = `%s`, wantErrMsg = `%s`", err.Error(), tt.wantErrMsg)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  = %#v,\nwant = %#v", got, tt.want)
+			}
+		})
+	}
+}
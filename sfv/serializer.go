@@ -0,0 +1,221 @@
+package sfv
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxInteger = 999999999999999
+	maxDecimal = 999999999999.999
+)
+
+// SerializeDictionary serialize Dictionary into header value (4.1.2)
+func SerializeDictionary(d Dictionary) (string, error) {
+	var b strings.Builder
+	for i, m := range d {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if err := writeKey(&b, m.Key); err != nil {
+			return "", err
+		}
+		if item, ok := m.Value.(Item); ok && item.Value == true {
+			if err := writeParams(&b, item.Params); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte('=')
+		if err := writeMember(&b, m.Value); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// SerializeList serialize List into header value (4.1.1)
+func SerializeList(l List) (string, error) {
+	var b strings.Builder
+	for i, m := range l {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if err := writeMember(&b, m); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// SerializeItem serialize Item into header value (4.1.3)
+func SerializeItem(i Item) (string, error) {
+	var b strings.Builder
+	if err := writeItem(&b, i); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// SerializeInnerList serialize InnerList (4.1.1.1)
+func SerializeInnerList(l InnerList) (string, error) {
+	var b strings.Builder
+	if err := writeInnerList(&b, l); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// SerializeParams serialize Params (4.1.1.2)
+func SerializeParams(p Params) (string, error) {
+	var b strings.Builder
+	if err := writeParams(&b, p); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func writeMember(b *strings.Builder, m Member) error {
+	switch m := m.(type) {
+	case Item:
+		return writeItem(b, m)
+	case InnerList:
+		return writeInnerList(b, m)
+	default:
+		return &Error{fmt.Sprintf("unsupported member type %T", m), nil}
+	}
+}
+
+func writeInnerList(b *strings.Builder, l InnerList) error {
+	b.WriteByte('(')
+	for i, item := range l.Items {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if err := writeItem(b, item); err != nil {
+			return err
+		}
+	}
+	b.WriteByte(')')
+	return writeParams(b, l.Params)
+}
+
+func writeItem(b *strings.Builder, i Item) error {
+	if err := writeBareItem(b, i.Value); err != nil {
+		return err
+	}
+	return writeParams(b, i.Params)
+}
+
+func writeParams(b *strings.Builder, p Params) error {
+	for _, param := range p {
+		b.WriteByte(';')
+		if err := writeKey(b, param.Key); err != nil {
+			return err
+		}
+		if param.Value == true {
+			continue
+		}
+		b.WriteByte('=')
+		if err := writeBareItem(b, param.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeKey(b *strings.Builder, k string) error {
+	if len(k) == 0 || (!isLcAlpha(k[0]) && k[0] != '*') {
+		return &Error{fmt.Sprintf("wrong key '%s'", k), nil}
+	}
+	for i := 1; i < len(k); i++ {
+		if !isKeyChar(k[i]) {
+			return &Error{fmt.Sprintf("wrong key '%s'", k), nil}
+		}
+	}
+	b.WriteString(k)
+	return nil
+}
+
+func writeBareItem(b *strings.Builder, v interface{}) error {
+	switch v := v.(type) {
+	case int:
+		return writeInteger(b, int64(v))
+	case int64:
+		return writeInteger(b, v)
+	case float64:
+		return writeDecimal(b, v)
+	case string:
+		return writeString(b, v)
+	case Token:
+		return writeToken(b, v)
+	case []byte:
+		b.WriteByte(':')
+		b.WriteString(base64.StdEncoding.EncodeToString(v))
+		b.WriteByte(':')
+		return nil
+	case bool:
+		if v {
+			b.WriteString("?1")
+		} else {
+			b.WriteString("?0")
+		}
+		return nil
+	default:
+		return &Error{fmt.Sprintf("unsupported item type %T", v), nil}
+	}
+}
+
+func writeInteger(b *strings.Builder, v int64) error {
+	if v > maxInteger || v < -maxInteger {
+		return &Error{fmt.Sprintf("integer %d out of range", v), nil}
+	}
+	b.WriteString(strconv.FormatInt(v, 10))
+	return nil
+}
+
+func writeDecimal(b *strings.Builder, v float64) error {
+	// 4.1.5 round to three decimal places, if the fourth decimal place is 5, round to the even number
+	v = math.RoundToEven(v*1000) / 1000
+	if math.IsNaN(v) || v > maxDecimal || v < -maxDecimal {
+		return &Error{fmt.Sprintf("decimal %v out of range", v), nil}
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	b.WriteString(s)
+	return nil
+}
+
+func writeString(b *strings.Builder, v string) error {
+	b.WriteByte('"')
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c < 0x20 || c > 0x7e {
+			return &Error{fmt.Sprintf("unsupported symbol 0x%02x in string", c), nil}
+		}
+		if c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('"')
+	return nil
+}
+
+func writeToken(b *strings.Builder, v Token) error {
+	if len(v) == 0 || (!isAlpha(v[0]) && v[0] != '*') {
+		return &Error{fmt.Sprintf("wrong token '%s'", v), nil}
+	}
+	for i := 1; i < len(v); i++ {
+		if !isTChar(v[i]) && v[i] != ':' && v[i] != '/' {
+			return &Error{fmt.Sprintf("wrong token '%s'", v), nil}
+		}
+	}
+	b.WriteString(string(v))
+	return nil
+}
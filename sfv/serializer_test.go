@@ -0,0 +1,165 @@
+package sfv
+
+import "testing"
+
+func TestSerializeDictionary(t *testing.T) {
+	tests := []struct {
+		name       string
+		d          Dictionary
+		want       string
+		wantErrMsg string
+	}{
+		{
+			name: "Signature-Input",
+			d: Dictionary{
+				{
+					Key: "sig1",
+					Value: InnerList{
+						Items:  []Item{{Value: "@method"}, {Value: "content-type"}},
+						Params: Params{{"created", int64(1618884473)}, {"keyid", `test"key`}},
+					},
+				},
+			},
+			want: `sig1=("@method" "content-type");created=1618884473;keyid="test\"key"`,
+		},
+		{
+			name: "Items",
+			d: Dictionary{
+				{Key: "a", Value: Item{Value: true, Params: Params{{"x", Token("tok")}}}},
+				{Key: "b", Value: Item{Value: false}},
+				{Key: "c", Value: Item{Value: []byte("hello")}},
+				{Key: "d", Value: Item{Value: 1.0005}},
+				{Key: "e", Value: Item{Value: -7}},
+			},
+			want: `a;x=tok, b=?0, c=:aGVsbG8=:, d=1.0, e=-7`,
+		},
+		{
+			name:       "Wrong key",
+			d:          Dictionary{{Key: "Key", Value: Item{Value: 1}}},
+			wantErrMsg: "StructuredFieldError: wrong key 'Key'",
+		},
+		{
+			name:       "Wrong string",
+			d:          Dictionary{{Key: "k", Value: Item{Value: "line\n"}}},
+			wantErrMsg: "StructuredFieldError: unsupported symbol 0x0a in string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SerializeDictionary(tt.d)
+			if err != nil && err.Error() != tt.wantErrMsg {
+				t.Errorf("error message = `%s`, wantErrMsg = `%s`", err.Error(), tt.wantErrMsg)
+			}
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf("expected error `%s`", tt.wantErrMsg)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("got  = %s,\nwant = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeItem(t *testing.T) {
+	tests := []struct {
+		name       string
+		i          Item
+		want       string
+		wantErrMsg string
+	}{
+		{name: "Integer", i: Item{Value: int64(-42)}, want: `-42`},
+		{name: "Integer out of range", i: Item{Value: int64(1000000000000000)}, wantErrMsg: "StructuredFieldError: integer 1000000000000000 out of range"},
+		{name: "Decimal", i: Item{Value: 1.5}, want: `1.5`},
+		{name: "Decimal without fraction", i: Item{Value: 2.0}, want: `2.0`},
+		{name: "Decimal rounding half even", i: Item{Value: 0.0025}, want: `0.002`},
+		{name: "Decimal rounding", i: Item{Value: 1.23456}, want: `1.235`},
+		{name: "Decimal out of range", i: Item{Value: 1e12}, wantErrMsg: "StructuredFieldError: decimal 1e+12 out of range"},
+		{name: "String", i: Item{Value: `say "hi" \o/`}, want: `"say \"hi\" \\o/"`},
+		{name: "Token", i: Item{Value: Token("*foo/bar:baz")}, want: `*foo/bar:baz`},
+		{name: "Wrong token", i: Item{Value: Token("1foo")}, wantErrMsg: "StructuredFieldError: wrong token '1foo'"},
+		{name: "Token with space", i: Item{Value: Token("foo bar")}, wantErrMsg: "StructuredFieldError: wrong token 'foo bar'"},
+		{name: "Byte sequence", i: Item{Value: []byte{0, 1, 2}}, want: `:AAEC:`},
+		{name: "Boolean", i: Item{Value: false, Params: Params{{"a", true}, {"b", false}}}, want: `?0;a;b=?0`},
+		{name: "Unsupported type", i: Item{Value: uint(1)}, wantErrMsg: "StructuredFieldError: unsupported item type uint"},
+		{name: "Unsupported parameter type", i: Item{Value: 1, Params: Params{{"a", []string{}}}}, wantErrMsg: "StructuredFieldError: unsupported item type []string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SerializeItem(tt.i)
+			if err != nil && err.Error() != tt.wantErrMsg {
+				t.Errorf("error message = `%s`, wantErrMsg = `%s`", err.Error(), tt.wantErrMsg)
+			}
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf("expected error `%s`", tt.wantErrMsg)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("got  = %s,\nwant = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeList(t *testing.T) {
+	l := List{
+		Item{Value: Token("sugar")},
+		InnerList{Items: []Item{{Value: "foo"}, {Value: int64(1), Params: Params{{"a", Token("b")}}}}, Params: Params{{"lvl", 5}}},
+		InnerList{},
+	}
+	want := `sugar, ("foo" 1;a=b);lvl=5, ()`
+	got, err := SerializeList(l)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != want {
+		t.Errorf("got  = %s,\nwant = %s", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		t      FieldType
+		header string
+		want   string
+	}{
+		{
+			name:   "Dictionary",
+			t:      DictionaryType,
+			header: `a=1,    b=2;x=1;y=2,   c=(a   b   c), d`,
+			want:   `a=1, b=2;x=1;y=2, c=(a b c), d`,
+		},
+		{
+			name:   "Dictionary with boolean values",
+			t:      DictionaryType,
+			header: `a=?1, b=?0;x=?1`,
+			want:   `a, b=?0;x`,
+		},
+		{
+			name:   "List",
+			t:      ListType,
+			header: `"foo",bar;baz=1.50,(1 2);q=:AAEC:`,
+			want:   `"foo", bar;baz=1.5, (1 2);q=:AAEC:`,
+		},
+		{
+			name:   "Item",
+			t:      ItemType,
+			header: `  -0.500;a  `,
+			want:   `-0.5;a`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse(tt.t, tt.header)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %s", err)
+			}
+			got, err := Serialize(v)
+			if err != nil {
+				t.Fatalf("unexpected serialize error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("got  = %s,\nwant = %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Package sfv implements Structured Field Values for HTTP (RFC 8941): items, lists, dictionaries,
+// inner lists and parameters
+package sfv
+
+import "fmt"
+
+// Error errors during parsing or serializing structured fields
+type Error struct {
+	Message string
+	Err     error
+}
+
+// Error error message
+func (e *Error) Error() string {
+	if e == nil {
+		return ""
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("StructuredFieldError: %s: %s", e.Message, e.Err.Error())
+	}
+	return fmt.Sprintf("StructuredFieldError: %s", e.Message)
+}
+
+// Unwrap wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Token token bare item (3.3.4)
+type Token string
+
+// Item bare item with parameters (3.3)
+// Value types: int64 (Integer), float64 (Decimal), string (String), Token, []byte (Byte Sequence), bool (Boolean)
+type Item struct {
+	Value  interface{}
+	Params Params
+}
+
+// InnerList array of items with parameters (3.1.1)
+type InnerList struct {
+	Items  []Item
+	Params Params
+}
+
+// Member list or dictionary member: Item or InnerList
+type Member interface {
+	isMember()
+}
+
+func (Item) isMember()      {}
+func (InnerList) isMember() {}
+
+// List array of members (3.1)
+type List []Member
+
+// Param parameter: key & bare item value
+type Param struct {
+	Key   string
+	Value interface{}
+}
+
+// Params ordered map of parameters (3.1.2)
+type Params []Param
+
+// Get get parameter value by key
+func (p Params) Get(key string) (interface{}, bool) {
+	for _, v := range p {
+		if v.Key == key {
+			return v.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set set parameter value. Value of existing parameter is overwritten, order is preserved
+func (p *Params) Set(key string, value interface{}) {
+	for i, v := range *p {
+		if v.Key == key {
+			(*p)[i].Value = value
+			return
+		}
+	}
+	*p = append(*p, Param{key, value})
+}
+
+// DictMember dictionary member: key & value
+type DictMember struct {
+	Key   string
+	Value Member
+}
+
+// Dictionary ordered map of members (3.2)
+type Dictionary []DictMember
+
+// Get get member by key
+func (d Dictionary) Get(key string) (Member, bool) {
+	for _, v := range d {
+		if v.Key == key {
+			return v.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set set member value. Value of existing member is overwritten, order is preserved
+func (d *Dictionary) Set(key string, value Member) {
+	for i, v := range *d {
+		if v.Key == key {
+			(*d)[i].Value = value
+			return
+		}
+	}
+	*d = append(*d, DictMember{key, value})
+}
+
+// Keys list of dictionary keys
+func (d Dictionary) Keys() []string {
+	keys := make([]string, len(d))
+	for i, v := range d {
+		keys[i] = v.Key
+	}
+	return keys
+}
+
+// FieldType top-level type of the structured field (3)
+type FieldType int
+
+// Top-level types of structured fields
+const (
+	ItemType FieldType = iota
+	ListType
+	DictionaryType
+)
+
+// Parse parse header value of the field with known type: result is Item, List or Dictionary
+func Parse(t FieldType, s string) (interface{}, error) {
+	switch t {
+	case ItemType:
+		return ParseItem(s)
+	case ListType:
+		return ParseList(s)
+	case DictionaryType:
+		return ParseDictionary(s)
+	default:
+		return nil, &Error{fmt.Sprintf("unknown field type %d", t), nil}
+	}
+}
+
+// Serialize serialize Item, List or Dictionary into header value
+func Serialize(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case Item:
+		return SerializeItem(v)
+	case List:
+		return SerializeList(v)
+	case Dictionary:
+		return SerializeDictionary(v)
+	default:
+		return "", &Error{fmt.Sprintf("unsupported structured field type %T", v), nil}
+	}
+}
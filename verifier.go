@@ -0,0 +1,108 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"time"
+)
+
+// SignatureFormat format of the message signature
+type SignatureFormat int
+
+// Signature formats detected by Verify
+const (
+	UnknownFormat SignatureFormat = iota
+	// CavageSignatureFormat draft-cavage-http-signatures-12 Signature header
+	CavageSignatureFormat
+	// CavageAuthorizationFormat draft-cavage-http-signatures-12 Authorization header with Signature scheme
+	CavageAuthorizationFormat
+	// MessageSignatureFormat RFC 9421 Signature-Input & Signature headers
+	MessageSignatureFormat
+)
+
+// String name of the signature format
+func (f SignatureFormat) String() string {
+	switch f {
+	case CavageSignatureFormat:
+		return "cavage-signature"
+	case CavageAuthorizationFormat:
+		return "cavage-authorization"
+	case MessageSignatureFormat:
+		return "rfc9421"
+	default:
+		return "unknown"
+	}
+}
+
+// VerificationResult result of the signature verification. Fields are filled as far as verification went,
+// so they could be used for logging of failed verifications as well
+// Format detected format of the signature
+// Label RFC 9421 signature label
+// KeyID & Algorithm key & signature hash algorithm (e.g. RSA-SHA256) used to sign the message
+// Components covered headers & components in signature order, e.g. `(request-target)` or `@query-param;name="pet"`
+// Created & Expires signature creation & expiration time (zero if not set)
+// Params extension params of the signature (e.g. `nonce`, `tag`), RFC 9421 non-string values are serialized
+// Metadata metadata of the secret
+// SignatureString draft-cavage signature string or RFC 9421 signature base built by the verifier, set in debug mode
+// only (see SetDebug)
+type VerificationResult struct {
+	Format          SignatureFormat
+	Label           string
+	KeyID           string
+	Algorithm       string
+	Components      []string
+	Created         time.Time
+	Expires         time.Time
+	Params          map[string]string
+	Metadata        map[string]string
+	SignatureString string
+}
+
+// Verify detect format of the request signature (RFC 9421 or draft-cavage Signature/Authorization header)
+// and verify it with the matching implementation. RFC 9421 signatures take precedence when both are present.
+// Result describes the first RFC 9421 signature not passed verification (or the first one if all are valid),
+// use VerifyMessageSignatures to get results of all signatures
+func (hs *HTTPSignatures) Verify(r *http.Request) (VerificationResult, error) {
+	var res VerificationResult
+	var err error
+	format := hs.DetectFormat(r)
+	switch format {
+	case MessageSignatureFormat:
+		res, err = hs.verifyMessageSignatureResult(r)
+	case CavageSignatureFormat:
+		res, err = hs.verifySignature(r.Header.Get(signatureHeader), requestMessage(r))
+	case CavageAuthorizationFormat:
+		res, err = hs.verifyAuthorization(r)
+	default:
+		err = &Error{"signature not found", withKind(nil, ErrMissingHeader)}
+	}
+	res.Format = format
+	return res, err
+}
+
+func (hs *HTTPSignatures) verifyMessageSignatureResult(r *http.Request) (VerificationResult, error) {
+	results, err := hs.VerifyMessageSignatures(r, MessageSignatureSelector{})
+	for _, res := range results {
+		if res.Err != nil {
+			return res.VerificationResult, err
+		}
+	}
+	if len(results) > 0 {
+		return results[0].VerificationResult, err
+	}
+	return VerificationResult{}, err
+}
+
+// DetectFormat detect format of the request signature: Signature-Input header is present for RFC 9421 signatures,
+// draft-cavage signature params are sent in Signature or Authorization header
+func (hs *HTTPSignatures) DetectFormat(r *http.Request) SignatureFormat {
+	if len(r.Header.Get(signatureInputHeader)) > 0 {
+		return MessageSignatureFormat
+	}
+	if len(r.Header.Get(signatureHeader)) > 0 {
+		return CavageSignatureFormat
+	}
+	if _, ok := hs.authorizationSignature(r); ok {
+		return CavageAuthorizationFormat
+	}
+	return UnknownFormat
+}
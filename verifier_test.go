@@ -0,0 +1,191 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	hmac, _ := messageSignaturesSecrets.Get("test-shared-secret")
+	rsa, _ := messageSignaturesSecrets.Get("test-key-rsa")
+	tests := []struct {
+		name        string
+		sign        func(hs *HTTPSignatures, r *http.Request)
+		want        SignatureFormat
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "RFC 9421 signature",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				_ = hs.SignMessage(hmac, r, MessageSignatureOptions{Components: []string{"@method", "@path", "date"}})
+			},
+			want:        MessageSignatureFormat,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Cavage Signature header",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				_ = hs.AddSignature(rsa, r)
+			},
+			want:        CavageSignatureFormat,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Cavage Authorization header",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				_ = hs.AddAuthorization(hmac, r)
+			},
+			want:        CavageAuthorizationFormat,
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Wrong Cavage Authorization header",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				_ = hs.AddAuthorization(hmac, r)
+				r.URL.Path = "/bar"
+			},
+			want:        CavageAuthorizationFormat,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "wrong signature: CryptoError: wrong signature",
+		},
+		{
+			name: "Other Authorization scheme",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				r.Header.Set("Authorization", "Bearer token")
+			},
+			want:        UnknownFormat,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "signature not found",
+		},
+		{
+			name:        "No signature",
+			sign:        func(hs *HTTPSignatures, r *http.Request) {},
+			want:        UnknownFormat,
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "signature not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(messageSignaturesSecrets)
+			r := getMessageSignatureRequestFunc()
+			tt.sign(hs, r)
+			res, err := hs.Verify(r)
+			got := res.Format
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestVerifyResult(t *testing.T) {
+	ss := NewSecretsStorage(map[string]Secret{
+		"test-key": {
+			KeyID:      "test-key",
+			PrivateKey: rsaPrivateKey,
+			PublicKey:  rsaPublicKey,
+			Algorithm:  "RSA-SHA256",
+			Metadata:   map[string]string{"owner": "billing"},
+		},
+	})
+	secret, _ := ss.Get("test-key")
+	created := time.Unix(time.Now().Unix(), 0)
+	tests := []struct {
+		name        string
+		sign        func(hs *HTTPSignatures, r *http.Request)
+		want        VerificationResult
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "RFC 9421 signature",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				_ = hs.SignMessage(secret, r, MessageSignatureOptions{
+					Label:      "client",
+					Components: []string{"@method", `@query-param;name="Pet"`, "date"},
+					Created:    created,
+					Expires:    created.Add(time.Minute),
+					Nonce:      "n-1",
+					Tag:        "app",
+				})
+			},
+			want: VerificationResult{
+				Format:     MessageSignatureFormat,
+				Label:      "client",
+				KeyID:      "test-key",
+				Algorithm:  "RSA-SHA256",
+				Components: []string{"@method", `@query-param;name="Pet"`, "date"},
+				Created:    created,
+				Expires:    created.Add(time.Minute),
+				Params:     map[string]string{"nonce": "n-1", "tag": "app"},
+				Metadata:   map[string]string{"owner": "billing"},
+			},
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Cavage signature",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "(expires)", "date"})
+				hs.SetDefaultExpiresPeriod(time.Hour)
+				_ = hs.AddSignature(secret, r)
+			},
+			want: VerificationResult{
+				Format:     CavageSignatureFormat,
+				KeyID:      "test-key",
+				Algorithm:  "RSA-SHA256",
+				Components: []string{"(request-target)", "(created)", "(expires)", "date"},
+				Created:    created,
+				Expires:    created.Add(time.Hour),
+				Params:     map[string]string{},
+				Metadata:   map[string]string{"owner": "billing"},
+			},
+			wantErrType: httpsignaturesErrType,
+		},
+		{
+			name: "Unknown key",
+			sign: func(hs *HTTPSignatures, r *http.Request) {
+				r.Header.Set("Signature", `keyId="unknown",algorithm="hmac-sha256",headers="date",signature="c2ln"`)
+			},
+			want: VerificationResult{
+				Format:     CavageSignatureFormat,
+				KeyID:      "unknown",
+				Algorithm:  "hmac-sha256",
+				Components: []string{"date"},
+				Params:     map[string]string{},
+			},
+			wantErrType: httpsignaturesErrType,
+			wantErrMsg:  "keyID 'unknown' not found: SecretError: secret not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(ss)
+			r := getMessageSignatureRequestFunc()
+			tt.sign(hs, r)
+			got, err := hs.Verify(r)
+			if got.Created.Sub(tt.want.Created) == time.Second {
+				// Second boundary passed between the test setup & signing
+				tt.want.Created, tt.want.Expires = got.Created, got.Expires
+			}
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestSignatureFormatString(t *testing.T) {
+	tests := []struct {
+		format SignatureFormat
+		want   string
+	}{
+		{UnknownFormat, "unknown"},
+		{CavageSignatureFormat, "cavage-signature"},
+		{CavageAuthorizationFormat, "cavage-authorization"},
+		{MessageSignatureFormat, "rfc9421"},
+	}
+	for _, tt := range tests {
+		if got := tt.format.String(); got != tt.want {
+			t.Errorf("format %d = %s, want %s", tt.format, got, tt.want)
+		}
+	}
+}